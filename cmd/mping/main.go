@@ -22,6 +22,8 @@ func Execute() {
 	cmd.AddCommand(
 		command.NewPingBatchCmd(),
 		command.NewConfigCmd(),
+		command.NewReplayCmd(),
+		command.NewQueryCmd(),
 	)
 	cmd.CompletionOptions.HiddenDefaultCmd = true
 	cmd.SetOutput(os.Stdout)
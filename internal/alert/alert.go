@@ -0,0 +1,177 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/servak/mping/internal/stats"
+)
+
+const (
+	StateFiring   = "firing"
+	StateResolved = "resolved"
+
+	actionTimeout = 10 * time.Second
+)
+
+// Notification describes a state change; it is the webhook payload
+type Notification struct {
+	Rule    string    `json:"rule"`
+	Target  string    `json:"target"`
+	State   string    `json:"state"` // StateFiring or StateResolved
+	Value   float64   `json:"value"` // Consecutive results, loss in percent or RTT in ms
+	Message string    `json:"message"`
+	Time    time.Time `json:"time"`
+}
+
+// Engine evaluates rules against the metrics and runs the actions of rules
+// whose state changed for a target
+type Engine struct {
+	rules  []*Rule
+	client *http.Client
+
+	mu      sync.Mutex
+	firing  map[string]bool // Keyed by rule and target key
+	actions sync.WaitGroup
+	failed  int
+	lastErr error
+}
+
+func NewEngine(rules []*Rule) *Engine {
+	return &Engine{
+		rules:  rules,
+		client: &http.Client{Timeout: actionTimeout},
+		firing: make(map[string]bool),
+	}
+}
+
+// Run evaluates the rules every period until ctx is done
+func (e *Engine) Run(ctx context.Context, p stats.MetricsProvider, every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			e.Evaluate(p.SortBy(stats.Host, true), time.Now())
+		}
+	}
+}
+
+// Evaluate checks every rule against ms and starts the actions of the rules
+// whose state changed. Actions run in the background; Wait waits for them.
+func (e *Engine) Evaluate(ms []stats.Metrics, now time.Time) {
+	for _, r := range e.rules {
+		for _, m := range ms {
+			name := m.GetName()
+			if !r.matches(name) {
+				continue
+			}
+			key := r.Name + "\x00" + m.GetKey()
+			e.mu.Lock()
+			firing := e.firing[key]
+			e.mu.Unlock()
+			holds, ok, value, message := r.check(m, firing)
+			if !ok || holds == firing {
+				continue
+			}
+			e.mu.Lock()
+			e.firing[key] = holds
+			e.mu.Unlock()
+
+			n := Notification{Rule: r.Name, Target: name, State: StateResolved, Value: value, Message: message, Time: now}
+			if holds {
+				n.State = StateFiring
+			}
+			e.notify(r, n)
+		}
+	}
+}
+
+// Firing returns whether the rule named rule currently fires for the target
+// with the given key
+func (e *Engine) Firing(rule, key string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.firing[rule+"\x00"+key]
+}
+
+// Wait waits for running actions and returns how many actions failed and the
+// last error
+func (e *Engine) Wait() (int, error) {
+	e.actions.Wait()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.failed, e.lastErr
+}
+
+func (e *Engine) notify(r *Rule, n Notification) {
+	if r.Exec != "" {
+		e.run(func(ctx context.Context) error { return runCommand(ctx, r.Exec, n) })
+	}
+	if r.Webhook != "" {
+		e.run(func(ctx context.Context) error { return e.post(ctx, r.Webhook, n) })
+	}
+}
+
+func (e *Engine) run(action func(ctx context.Context) error) {
+	e.actions.Add(1)
+	go func() {
+		defer e.actions.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), actionTimeout)
+		defer cancel()
+		if err := action(ctx); err != nil {
+			e.mu.Lock()
+			e.failed++
+			e.lastErr = err
+			e.mu.Unlock()
+		}
+	}()
+}
+
+// runCommand runs command with sh, passing the notification in MPING_* variables
+func runCommand(ctx context.Context, command string, n Notification) error {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Env = append(os.Environ(),
+		"MPING_RULE="+n.Rule,
+		"MPING_TARGET="+n.Target,
+		"MPING_STATE="+n.State,
+		"MPING_VALUE="+strconv.FormatFloat(n.Value, 'f', -1, 64),
+		"MPING_MESSAGE="+n.Message,
+		"MPING_TIME="+n.Time.Format(time.RFC3339),
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("alert %s: command failed: %w: %s", n.Rule, err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+func (e *Engine) post(ctx context.Context, url string, n Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return fmt.Errorf("alert %s: failed to encode notification: %w", n.Rule, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("alert %s: %w", n.Rule, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("alert %s: webhook failed: %w", n.Rule, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("alert %s: webhook returned %s", n.Rule, resp.Status)
+	}
+	return nil
+}
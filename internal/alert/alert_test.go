@@ -0,0 +1,184 @@
+package alert
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/servak/mping/internal/stats"
+)
+
+// fakeMetrics implements the parts of stats.Metrics the rules read
+type fakeMetrics struct {
+	stats.Metrics
+	key        string
+	name       string
+	failures   int
+	successes  int
+	failDetail string
+	total      int
+	loss       float64
+	rtt        time.Duration
+}
+
+func (m *fakeMetrics) GetKey() string                                    { return m.key }
+func (m *fakeMetrics) GetName() string                                   { return m.name }
+func (m *fakeMetrics) GetConsecutiveFailures() int                       { return m.failures }
+func (m *fakeMetrics) GetConsecutiveSuccesses() int                      { return m.successes }
+func (m *fakeMetrics) GetLastFailDetail() string                         { return m.failDetail }
+func (m *fakeMetrics) GetSuccessfulInPeriod(time.Duration) int           { return m.total }
+func (m *fakeMetrics) GetFailedInPeriod(time.Duration) int               { return 0 }
+func (m *fakeMetrics) GetLossInPeriod(time.Duration) float64             { return m.loss }
+func (m *fakeMetrics) GetAverageRTTInPeriod(time.Duration) time.Duration { return m.rtt }
+
+func TestRuleValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		rule Rule
+		err  string
+	}{
+		{"down", Rule{Name: "down", DownAfter: 3, UpAfter: 2, Exec: "true"}, ""},
+		{"loss with webhook", Rule{Name: "loss", LossAbove: 20, Window: 5 * time.Minute, Webhook: "https://example.com/hook"}, ""},
+		{"no name", Rule{DownAfter: 3, Exec: "true"}, "name is required"},
+		{"no condition", Rule{Name: "x", Exec: "true"}, "exactly one of"},
+		{"two conditions", Rule{Name: "x", DownAfter: 3, RTTAbove: time.Second, Exec: "true"}, "exactly one of"},
+		{"no action", Rule{Name: "x", DownAfter: 3}, "exec or webhook is required"},
+		{"bad loss", Rule{Name: "x", LossAbove: 100, Exec: "true"}, "invalid loss_above"},
+		{"long window", Rule{Name: "x", LossAbove: 10, Window: time.Hour, Exec: "true"}, "invalid window"},
+		{"bad webhook", Rule{Name: "x", DownAfter: 3, Webhook: "example.com/hook"}, "invalid webhook URL"},
+		{"long down_after", Rule{Name: "x", DownAfter: stats.DefaultHistorySize + 1, Exec: "true"}, "must be at most"},
+		{"long up_after", Rule{Name: "x", DownAfter: 3, UpAfter: 150, Exec: "true"}, "must be at most"},
+		{"up_after without down_after", Rule{Name: "x", LossAbove: 10, UpAfter: 2, Exec: "true"}, "up_after is only valid with down_after"},
+		{"bad pattern", Rule{Name: "x", DownAfter: 3, Exec: "true", Targets: []string{"["}}, "invalid target pattern"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.rule.Validate()
+			if tt.err == "" && err != nil {
+				t.Errorf("Validate() error = %v", err)
+			}
+			if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Errorf("Expected an error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestEngineDownRuleWebhook(t *testing.T) {
+	var mu sync.Mutex
+	var received []Notification
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var n Notification
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Unexpected request %s with %s", r.Method, r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&n); err != nil {
+			t.Errorf("Invalid payload: %v", err)
+		}
+		mu.Lock()
+		received = append(received, n)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	rule := &Rule{Name: "down", DownAfter: 3, UpAfter: 2, Webhook: server.URL, Targets: []string{"db.*"}}
+	engine := NewEngine([]*Rule{rule})
+	target := &fakeMetrics{key: "db.example", name: "db.example(192.0.2.1)", failures: 2}
+	other := &fakeMetrics{key: "198.51.100.1", name: "198.51.100.1", failures: 10}
+	now := time.Now()
+	evaluate := func() {
+		engine.Evaluate([]stats.Metrics{target, other}, now)
+		engine.Wait()
+	}
+
+	evaluate()
+	target.failures, target.failDetail = 3, "timeout"
+	evaluate()
+	evaluate() // Still down; no second notification
+	target.name = "db.example(192.0.2.2)"
+	evaluate() // Re-resolved while down; still the same target
+	target.failures, target.successes = 0, 1
+	evaluate()
+	if !engine.Firing("down", "db.example") {
+		t.Error("Expected the target to stay down until 2 successes")
+	}
+	target.successes = 2
+	evaluate()
+
+	if n, err := engine.Wait(); n != 0 {
+		t.Fatalf("Expected no failed actions, got %d: %v", n, err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 2 {
+		t.Fatalf("Expected a down and an up notification, got %+v", received)
+	}
+	down, up := received[0], received[1]
+	if down.Rule != "down" || down.Target != "db.example(192.0.2.1)" || down.State != StateFiring || down.Value != 3 || !strings.Contains(down.Message, "timeout") || !down.Time.Equal(now) {
+		t.Errorf("Unexpected down notification: %+v", down)
+	}
+	if up.State != StateResolved || up.Value != 2 {
+		t.Errorf("Unexpected up notification: %+v", up)
+	}
+}
+
+func TestEngineThresholdRulesExec(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "alerts.log")
+	command := `echo "$MPING_RULE $MPING_TARGET $MPING_STATE $MPING_VALUE" >> ` + out
+	engine := NewEngine([]*Rule{
+		{Name: "loss", LossAbove: 20, Exec: command},
+		{Name: "slow", RTTAbove: 100 * time.Millisecond, Exec: command},
+	})
+	m := &fakeMetrics{key: "example.com", name: "example.com", total: 0, loss: 50, rtt: 0}
+
+	// Without results in the window nothing changes
+	engine.Evaluate([]stats.Metrics{m}, time.Now())
+	engine.Wait()
+	if _, err := os.Stat(out); err == nil {
+		t.Fatal("Expected no alerts without data")
+	}
+
+	m.total, m.rtt = 10, 150*time.Millisecond
+	engine.Evaluate([]stats.Metrics{m}, time.Now())
+	engine.Wait()
+	m.loss, m.rtt = 5, 20*time.Millisecond
+	engine.Evaluate([]stats.Metrics{m}, time.Now())
+	engine.Wait()
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("Expected the command to run: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	sort.Strings(lines) // Actions run concurrently
+	want := []string{
+		"loss example.com firing 50",
+		"loss example.com resolved 5",
+		"slow example.com firing 150",
+		"slow example.com resolved 20",
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Expected\n%s\ngot\n%s", strings.Join(want, "\n"), strings.Join(lines, "\n"))
+	}
+}
+
+func TestEngineActionErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	engine := NewEngine([]*Rule{{Name: "down", DownAfter: 1, Webhook: server.URL, Exec: "exit 3"}})
+	engine.Evaluate([]stats.Metrics{&fakeMetrics{key: "a", name: "a", failures: 1}}, time.Now())
+	n, err := engine.Wait()
+	if n != 2 || err == nil {
+		t.Errorf("Expected both actions to fail, got %d: %v", n, err)
+	}
+}
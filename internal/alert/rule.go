@@ -0,0 +1,139 @@
+// Package alert runs commands or calls webhooks when targets change state,
+// e.g. go down after a number of consecutive failures.
+package alert
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/servak/mping/internal/stats"
+)
+
+// DefaultWindow is the period loss_above and rtt_above are evaluated over by default
+const DefaultWindow = time.Minute
+
+// Rule fires its actions when its condition starts to hold for a target and
+// again when it stops holding. Exactly one condition must be set.
+type Rule struct {
+	Name    string   `yaml:"name"`
+	Targets []string `yaml:"targets,omitempty"` // Glob patterns of target names; all targets if empty
+
+	// Conditions
+	DownAfter int           `yaml:"down_after,omitempty"` // Consecutive failures that make a target down
+	UpAfter   int           `yaml:"up_after,omitempty"`   // Consecutive successes that make it up again (default 1)
+	LossAbove float64       `yaml:"loss_above,omitempty"` // Loss in percent over the window
+	RTTAbove  time.Duration `yaml:"rtt_above,omitempty"`  // Average RTT over the window
+	Window    time.Duration `yaml:"window,omitempty"`     // Period for loss_above and rtt_above (default 1m)
+
+	// Actions
+	Exec    string `yaml:"exec,omitempty"`    // Shell command run with MPING_* environment variables
+	Webhook string `yaml:"webhook,omitempty"` // URL a JSON notification is POSTed to
+}
+
+// Validate checks that the rule has one condition and at least one action
+func (r *Rule) Validate() error {
+	if r.Name == "" {
+		return errors.New("name is required")
+	}
+	conditions := 0
+	for _, set := range []bool{r.DownAfter != 0, r.LossAbove != 0, r.RTTAbove != 0} {
+		if set {
+			conditions++
+		}
+	}
+	if conditions != 1 {
+		return errors.New("exactly one of down_after, loss_above and rtt_above is required")
+	}
+	if r.DownAfter < 0 || r.UpAfter < 0 {
+		return errors.New("down_after and up_after must not be negative")
+	}
+	if r.UpAfter != 0 && r.DownAfter == 0 {
+		return errors.New("up_after is only valid with down_after")
+	}
+	// Streaks are counted over the recent history, so longer ones never match
+	if r.DownAfter > stats.DefaultHistorySize || r.UpAfter > stats.DefaultHistorySize {
+		return fmt.Errorf("down_after and up_after must be at most %d", stats.DefaultHistorySize)
+	}
+	if r.LossAbove < 0 || r.LossAbove >= 100 {
+		return fmt.Errorf("invalid loss_above %v (must be between 0 and 100)", r.LossAbove)
+	}
+	if r.RTTAbove < 0 {
+		return errors.New("rtt_above must not be negative")
+	}
+	if r.Window < 0 || r.Window > stats.MaxStatsWindow {
+		return fmt.Errorf("invalid window %v (must be at most %v)", r.Window, stats.MaxStatsWindow)
+	}
+	for _, p := range r.Targets {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid target pattern %q", p)
+		}
+	}
+	if r.Exec == "" && r.Webhook == "" {
+		return errors.New("exec or webhook is required")
+	}
+	if r.Webhook != "" {
+		u, err := url.Parse(r.Webhook)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid webhook URL %q", r.Webhook)
+		}
+	}
+	return nil
+}
+
+// matches reports whether the rule applies to the target named name
+func (r *Rule) matches(name string) bool {
+	if len(r.Targets) == 0 {
+		return true
+	}
+	for _, p := range r.Targets {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) window() time.Duration {
+	if r.Window == 0 {
+		return DefaultWindow
+	}
+	return r.Window
+}
+
+// check returns whether the rule's condition holds for m, or ok=false when
+// there is not enough data to change the current state, and a description
+// of the value it was decided on
+func (r *Rule) check(m stats.Metrics, firing bool) (holds, ok bool, value float64, message string) {
+	switch {
+	case r.DownAfter > 0:
+		failures, successes := m.GetConsecutiveFailures(), m.GetConsecutiveSuccesses()
+		if !firing && failures >= r.DownAfter {
+			msg := fmt.Sprintf("down after %d consecutive failures", failures)
+			if detail := m.GetLastFailDetail(); detail != "" {
+				msg += ": " + detail
+			}
+			return true, true, float64(failures), msg
+		}
+		if firing && successes >= max(r.UpAfter, 1) {
+			return false, true, float64(successes), fmt.Sprintf("up after %d consecutive successes", successes)
+		}
+		return firing, false, 0, ""
+	case r.LossAbove > 0:
+		d := r.window()
+		if m.GetSuccessfulInPeriod(d)+m.GetFailedInPeriod(d) == 0 {
+			return firing, false, 0, ""
+		}
+		loss := m.GetLossInPeriod(d)
+		return loss > r.LossAbove, true, loss, fmt.Sprintf("loss %.1f%% over %v (threshold %.1f%%)", loss, d, r.LossAbove)
+	default:
+		d := r.window()
+		rtt := m.GetAverageRTTInPeriod(d)
+		if rtt == 0 {
+			return firing, false, 0, ""
+		}
+		return rtt > r.RTTAbove, true, float64(rtt.Microseconds()) / 1000, fmt.Sprintf("average RTT %v over %v (threshold %v)", rtt.Round(time.Microsecond), d, r.RTTAbove)
+	}
+}
@@ -9,7 +9,6 @@ import (
 	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/spf13/cobra"
 
-	"github.com/servak/mping/internal/config"
 	"github.com/servak/mping/internal/prober"
 	"github.com/servak/mping/internal/stats"
 	"github.com/servak/mping/internal/ui/shared"
@@ -53,6 +52,18 @@ mping batch dns://8.8.8.8/google.com`,
 			if err != nil {
 				return err
 			}
+			outageFailures, outageRecoveries, err := getOutageThresholds(flags)
+			if err != nil {
+				return err
+			}
+			recordPath, err := flags.GetString("record")
+			if err != nil {
+				return err
+			}
+			storeDir, err := flags.GetString("store")
+			if err != nil {
+				return err
+			}
 
 			hosts := parseHostnames(args, filename)
 			if len(hosts) == 0 {
@@ -61,13 +72,17 @@ mping batch dns://8.8.8.8/google.com`,
 				return nil
 			}
 
-			cfg, _ := config.LoadFile(path)
+			cfg, err := loadConfig(path)
+			if err != nil {
+				return err
+			}
 			_interval := time.Duration(interval) * time.Millisecond
 			_timeout := time.Duration(timeout) * time.Millisecond
 
 			// Create ProbeManager and MetricsManager
 			probeManager := prober.NewProbeManager(cfg.Prober, cfg.Default)
 			metricsManager := stats.NewMetricsManager()
+			metricsManager.SetOutageThresholds(outageFailures, outageRecoveries)
 			
 			// Add targets
 			err = probeManager.AddTargets(hosts...)
@@ -77,6 +92,14 @@ mping batch dns://8.8.8.8/google.com`,
 			
 			// Subscribe to events for metrics collection
 			metricsManager.Subscribe(probeManager.Events())
+			finishRecording, err := recordEvents(recordPath, probeManager, _interval, _timeout)
+			if err != nil {
+				return err
+			}
+			closeStore, err := storeResults(storeDir, probeManager)
+			if err != nil {
+				return err
+			}
 			
 			// Start probing with timeout context
 			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(counter)*_interval)
@@ -88,6 +111,7 @@ mping batch dns://8.8.8.8/google.com`,
 					fmt.Printf("ProbeManager error: %v\n", err)
 				}
 			}()
+			waitAlerts := startAlerts(ctx, cfg.Alerts, metricsManager, _interval)
 			
 			// Wait for specified duration
 			for counter > 0 {
@@ -98,12 +122,35 @@ mping batch dns://8.8.8.8/google.com`,
 			
 			// Stop probing
 			probeManager.Stop()
+			if err := finishRecording(); err != nil {
+				cmd.PrintErrln(err)
+			}
+			if err := closeStore(); err != nil {
+				cmd.PrintErrln(err)
+			}
+			if n := probeManager.Dropped(); n > 0 {
+				cmd.PrintErrf("%d probe events were dropped because their consumers fell behind\n", n)
+			}
+			for _, n := range metricsManager.GetProberNotices() {
+				cmd.PrintErrf("%s: note: %s\n", n.Prober, n.Message)
+			}
+			for _, e := range metricsManager.GetProberErrors() {
+				cmd.PrintErrf("%s: %s\n", e.Prober, e.Message)
+			}
+			if n, err := waitAlerts(); n > 0 {
+				cmd.PrintErrf("%d alert actions failed, the last with: %v\n", n, err)
+			}
 			cmd.Print("\r")
-			metrics := metricsManager.SortBy(stats.Success, true)
-			tableData := shared.NewTableData(metrics, stats.Success, true)
+			metrics := stats.GroupMetrics(metricsManager.SortBy(stats.Success, true))
+			tableData := shared.NewTableData(metrics, stats.Success, true, shared.Columns{Spread: cfg.UI.SpreadColumns})
 			t := tableData.ToGoPrettyTable()
 			t.SetStyle(table.StyleLight)
 			cmd.Println(t.Render())
+
+			// Outage summary
+			outages := shared.NewOutageTableData(metrics).ToGoPrettyTable()
+			outages.SetStyle(table.StyleLight)
+			cmd.Println(outages.Render())
 			return nil
 		},
 	}
@@ -114,6 +161,9 @@ mping batch dns://8.8.8.8/google.com`,
 	flags.IntP("interval", "i", 1000, "interval(ms)")
 	flags.IntP("timeout", "t", 1000, "timeout(ms)")
 	flags.IntP("count", "", 10, "repeat count")
+	addOutageFlags(flags)
+	flags.String("record", "", "record all probe events to this file (NDJSON) for mping replay")
+	flags.String("store", "", "store every probe result in this directory for mping query")
 
 	return cmd
 }
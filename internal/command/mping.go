@@ -9,7 +9,6 @@ import (
 	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/spf13/cobra"
 
-	"github.com/servak/mping/internal/config"
 	"github.com/servak/mping/internal/prober"
 	"github.com/servak/mping/internal/stats"
 	"github.com/servak/mping/internal/ui/shared"
@@ -60,6 +59,30 @@ mping dns://8.8.8.8/google.com`,
 			if err != nil {
 				return err
 			}
+			resolveInterval, err := flags.GetDuration("resolve-interval")
+			if err != nil {
+				return err
+			}
+			bindDevice, err := flags.GetString("bind-device")
+			if err != nil {
+				return err
+			}
+			outageFailures, outageRecoveries, err := getOutageThresholds(flags)
+			if err != nil {
+				return err
+			}
+			recordPath, err := flags.GetString("record")
+			if err != nil {
+				return err
+			}
+			storeDir, err := flags.GetString("store")
+			if err != nil {
+				return err
+			}
+			stateFile, err := flags.GetString("state-file")
+			if err != nil {
+				return err
+			}
 
 			hosts := parseHostnames(args, filename)
 			if len(hosts) == 0 {
@@ -68,15 +91,26 @@ mping dns://8.8.8.8/google.com`,
 				return nil
 			}
 
-			cfg, _ := config.LoadFile(path)
+			cfg, err := loadConfig(path)
+			if err != nil {
+				return err
+			}
 			cfg.SetTitle(title)
 			cfg.SetSourceInterface(sourceInterface)
+			cfg.SetResolveInterval(resolveInterval)
+			cfg.SetBindToDevice(bindDevice)
 			_interval := time.Duration(interval) * time.Millisecond
 			_timeout := time.Duration(timeout) * time.Millisecond
 
 			// Create ProbeManager and MetricsManager
 			probeManager := prober.NewProbeManager(cfg.Prober, cfg.Default)
 			metricsManager := stats.NewMetricsManager()
+			metricsManager.SetOutageThresholds(outageFailures, outageRecoveries)
+			if stateFile != "" {
+				if err := metricsManager.LoadState(stateFile); err != nil {
+					return err
+				}
+			}
 
 			// Add targets
 			err = probeManager.AddTargets(hosts...)
@@ -86,6 +120,14 @@ mping dns://8.8.8.8/google.com`,
 
 			// Subscribe to events for metrics collection
 			metricsManager.Subscribe(probeManager.Events())
+			finishRecording, err := recordEvents(recordPath, probeManager, _interval, _timeout)
+			if err != nil {
+				return err
+			}
+			closeStore, err := storeResults(storeDir, probeManager)
+			if err != nil {
+				return err
+			}
 
 			// Start probing in background
 			ctx, cancel := context.WithCancel(context.Background())
@@ -96,16 +138,39 @@ mping dns://8.8.8.8/google.com`,
 					fmt.Printf("ProbeManager error: %v\n", err)
 				}
 			}()
+			if stateFile != "" {
+				go saveStatePeriodically(ctx, metricsManager, stateFile)
+			}
+			waitAlerts := startAlerts(ctx, cfg.Alerts, metricsManager, _interval)
 
 			// Start TUI
-			startTUI(metricsManager, cfg.UI, _interval, _timeout)
+			startTUI(metricsManager, probeManager, cfg.UI, _interval, _timeout)
 
 			// Stop probing when TUI exits
 			probeManager.Stop()
+			cancel()
+			if err := finishRecording(); err != nil {
+				cmd.PrintErrln(err)
+			}
+			if err := closeStore(); err != nil {
+				cmd.PrintErrln(err)
+			}
+			if n := probeManager.Dropped(); n > 0 {
+				cmd.PrintErrf("%d probe events were dropped because their consumers fell behind\n", n)
+			}
+			if n, err := waitAlerts(); n > 0 {
+				cmd.PrintErrf("%d alert actions failed, the last with: %v\n", n, err)
+			}
+
+			if stateFile != "" {
+				if err := metricsManager.SaveState(stateFile); err != nil {
+					cmd.PrintErrln(err)
+				}
+			}
 
 			// Final results
-			metrics := metricsManager.SortBy(stats.Fail, false)
-			tableData := shared.NewTableData(metrics, stats.Fail, false)
+			metrics := stats.GroupMetrics(metricsManager.SortBy(stats.Fail, false))
+			tableData := shared.NewTableData(metrics, stats.Fail, false, shared.Columns{Spread: cfg.UI.SpreadColumns})
 			t := tableData.ToGoPrettyTable()
 			t.SetStyle(table.StyleLight)
 			cmd.Println(t.Render())
@@ -118,14 +183,42 @@ mping dns://8.8.8.8/google.com`,
 	flags.StringP("title", "n", "", "print title")
 	flags.StringP("config", "c", "~/.mping.yml", "config path")
 	flags.StringP("interface", "I", "", "source interface (name or IP address)")
+	flags.String("bind-device", "", "bind sockets to a network device or VRF (Linux only)")
 	flags.IntP("interval", "i", 1000, "interval(ms)")
 	flags.IntP("timeout", "t", 1000, "timeout(ms)")
+	flags.Duration("resolve-interval", 0, "re-resolve target hostnames periodically, e.g. 30s (0 disables)")
+	addOutageFlags(flags)
+	flags.String("record", "", "record all probe events to this file (NDJSON) for mping replay")
+	flags.String("store", "", "store every probe result in this directory for mping query")
+	flags.String("state-file", "", "save statistics to this file periodically and restore them on startup")
 
 	return cmd
 }
 
-func startTUI(manager stats.MetricsManager, cfg *shared.Config, interval, timeout time.Duration) {
+// stateSaveInterval is how often --state-file is written while running
+const stateSaveInterval = 10 * time.Second
+
+// saveStatePeriodically snapshots the metrics to path until ctx is done. Errors
+// are not shown while the TUI owns the terminal; the final save reports them.
+func saveStatePeriodically(ctx context.Context, mm stats.StatePersister, path string) {
+	ticker := time.NewTicker(stateSaveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			mm.SaveState(path)
+		}
+	}
+}
+
+// startTUI runs the TUI until it is closed; queue may be nil when there are no probers
+func startTUI(manager stats.MetricsManager, queue prober.QueueStatus, cfg *shared.Config, interval, timeout time.Duration) {
 	app := tui.NewTUIApp(manager, cfg, interval, timeout)
+	if queue != nil {
+		app.SetQueueStatus(queue)
+	}
 
 	refreshTime := time.Millisecond * 250 // Minimum refresh time that can be set
 	if refreshTime < (interval / 2) {
@@ -0,0 +1,197 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+
+	"github.com/servak/mping/internal/prober"
+	"github.com/servak/mping/internal/store"
+	"github.com/servak/mping/internal/ui/shared"
+)
+
+func NewQueryCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "query [TARGET]...",
+		Short: "Reports loss and RTT from probe results stored with --store",
+		Args:  cobra.MinimumNArgs(0),
+		Example: `mping query --store ~/.mping-results
+mping query --store ~/.mping-results --last 7d --step 1d 192.0.2.1
+mping query --store ~/.mping-results --from "2026-10-18 09:00" --to "2026-10-18 12:00"`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			flags := cmd.Flags()
+			dir, err := flags.GetString("store")
+			if err != nil {
+				return err
+			}
+			if dir == "" {
+				return errors.New("--store is required")
+			}
+			last, err := flags.GetString("last")
+			if err != nil {
+				return err
+			}
+			fromText, err := flags.GetString("from")
+			if err != nil {
+				return err
+			}
+			toText, err := flags.GetString("to")
+			if err != nil {
+				return err
+			}
+			stepText, err := flags.GetString("step")
+			if err != nil {
+				return err
+			}
+
+			to := time.Now()
+			if toText != "" {
+				if to, err = parseTime(toText); err != nil {
+					return err
+				}
+			}
+			var from time.Time
+			if fromText != "" {
+				if from, err = parseTime(fromText); err != nil {
+					return err
+				}
+			} else {
+				d, err := parseDays(last)
+				if err != nil {
+					return fmt.Errorf("invalid --last: %w", err)
+				}
+				from = to.Add(-d)
+			}
+			if !from.Before(to) {
+				return errors.New("the start of the range must be before its end")
+			}
+			var step time.Duration
+			if stepText != "" {
+				if step, err = parseDays(stepText); err != nil {
+					return fmt.Errorf("invalid --step: %w", err)
+				}
+			}
+
+			log, err := store.OpenSegmentLogReadOnly(dir)
+			if err != nil {
+				return err
+			}
+			defer log.Close()
+			summaries, err := store.Summarize(log, from, to, step, args)
+			if err != nil {
+				return err
+			}
+			if len(summaries) == 0 {
+				cmd.Printf("No results between %s and %s\n", from.Format(time.DateTime), to.Format(time.DateTime))
+				return nil
+			}
+			t := summaryTable(summaries, step > 0).ToGoPrettyTable()
+			t.SetStyle(table.StyleLight)
+			cmd.Println(t.Render())
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.String("store", "", "directory the results were stored in")
+	flags.String("last", "24h", "query this far back from --to, e.g. 30m, 12h or 7d")
+	flags.String("from", "", "start of the range, e.g. \"2026-10-18 09:00\" (overrides --last)")
+	flags.String("to", "", "end of the range (default now)")
+	flags.String("step", "", "report each period of this length separately, e.g. 1h or 1d")
+
+	return cmd
+}
+
+// summaryTable creates a table of stored results, with a Period column
+// when the summaries cover parts of the queried range
+func summaryTable(summaries []store.Summary, withPeriod bool) *shared.TableData {
+	headers := []string{"Host", "Sent", "Succ", "Fail", "Loss", "Avg", "Best", "Worst"}
+	if withPeriod {
+		headers = append([]string{headers[0], "Period"}, headers[1:]...)
+	}
+	df := shared.DurationFormater
+	rows := make([][]string, len(summaries))
+	for i, s := range summaries {
+		rows[i] = []string{
+			s.Key,
+			fmt.Sprintf("%d", s.Total()),
+			fmt.Sprintf("%d", s.Successful),
+			fmt.Sprintf("%d", s.Failed),
+			fmt.Sprintf("%5.1f%%", s.Loss()),
+			df(s.AverageRTT()),
+			df(s.MinimumRTT),
+			df(s.MaximumRTT),
+		}
+		if withPeriod {
+			end := s.End.Format("15:04")
+			if s.End.YearDay() != s.Start.YearDay() || s.End.Year() != s.Start.Year() {
+				end = s.End.Format("2006-01-02 15:04")
+			}
+			period := s.Start.Format("2006-01-02 15:04") + " - " + end
+			rows[i] = append([]string{rows[i][0], period}, rows[i][1:]...)
+		}
+	}
+	return &shared.TableData{
+		Headers: headers,
+		Rows:    rows,
+	}
+}
+
+// parseTime parses a local time such as "2026-10-18 09:00", or an RFC 3339 time
+func parseTime(s string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, time.DateTime, "2006-01-02 15:04", "2006-01-02T15:04", time.DateOnly} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", s)
+}
+
+// parseDays parses a positive duration, which may also be given in days (e.g. "7d")
+func parseDays(s string) (time.Duration, error) {
+	var d time.Duration
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		if d, err = time.ParseDuration(s); err != nil {
+			return 0, err
+		}
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("duration %q must be positive", s)
+	}
+	return d, nil
+}
+
+// storeResults stores the probe results of pm in dir, if dir is set. It
+// returns a function that waits until the results are written and closes the store.
+func storeResults(dir string, pm prober.ProbeManager) (func() error, error) {
+	if dir == "" {
+		return func() error { return nil }, nil
+	}
+	log, err := store.OpenSegmentLog(dir)
+	if err != nil {
+		return nil, err
+	}
+	w := store.NewWriter(log)
+	sub := pm.Subscribe("store", writerBuffer, prober.Block)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		w.WriteAll(sub.Events())
+	}()
+	return func() error {
+		<-done
+		return w.Close()
+	}, nil
+}
@@ -0,0 +1,151 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+
+	"github.com/servak/mping/internal/prober"
+	"github.com/servak/mping/internal/record"
+	"github.com/servak/mping/internal/stats"
+	"github.com/servak/mping/internal/ui/shared"
+)
+
+func NewReplayCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "replay FILE",
+		Short: "Plays back a recording made with --record in the TUI",
+		Args:  cobra.ExactArgs(1),
+		Example: `mping replay events.ndjson
+mping replay events.ndjson --speed 10x`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			flags := cmd.Flags()
+			s, err := flags.GetString("speed")
+			if err != nil {
+				return err
+			}
+			speed, err := parseSpeed(s)
+			if err != nil {
+				return err
+			}
+			path, err := flags.GetString("config")
+			if err != nil {
+				return err
+			}
+			outageFailures, outageRecoveries, err := getOutageThresholds(flags)
+			if err != nil {
+				return err
+			}
+
+			f, err := os.Open(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to open recording: %w", err)
+			}
+			defer f.Close()
+			player, err := record.NewPlayer(f)
+			if err != nil {
+				return err
+			}
+
+			cfg, err := loadConfig(path)
+			if err != nil {
+				return err
+			}
+			cfg.SetTitle(fmt.Sprintf("replay %s (%s)", filepath.Base(args[0]), s))
+			metricsManager := stats.NewMetricsManager()
+			metricsManager.SetOutageThresholds(outageFailures, outageRecoveries)
+			events := make(chan *prober.Event, 1000)
+			metricsManager.Subscribe(events)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			errc := make(chan error, 1)
+			go func() {
+				defer close(events)
+				errc <- player.Play(ctx, speed, events)
+			}()
+
+			startTUI(metricsManager, nil, cfg.UI, player.Header.Interval, player.Header.Timeout)
+			cancel()
+
+			metrics := stats.GroupMetrics(metricsManager.SortBy(stats.Fail, false))
+			tableData := shared.NewTableData(metrics, stats.Fail, false, shared.Columns{Spread: cfg.UI.SpreadColumns})
+			t := tableData.ToGoPrettyTable()
+			t.SetStyle(table.StyleLight)
+			cmd.Println(t.Render())
+			if err := <-errc; err != nil && !errors.Is(err, context.Canceled) {
+				return err
+			}
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringP("config", "c", "~/.mping.yml", "config path")
+	flags.String("speed", "1x", "playback speed, e.g. 10x (0 plays back without delays)")
+	addOutageFlags(flags)
+
+	return cmd
+}
+
+// parseSpeed parses a playback speed such as "10x", "0.5x" or "2"
+func parseSpeed(s string) (float64, error) {
+	speed, err := strconv.ParseFloat(strings.TrimSuffix(strings.ToLower(s), "x"), 64)
+	if err != nil || speed < 0 {
+		return 0, fmt.Errorf("invalid speed %q", s)
+	}
+	return speed, nil
+}
+
+// recordEvents records the events of pm to path, if path is set. It returns a
+// function that waits until the events are written and closes the recording.
+func recordEvents(path string, pm prober.ProbeManager, interval, timeout time.Duration) (func() error, error) {
+	if path == "" {
+		return func() error { return nil }, nil
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create recording: %w", err)
+	}
+	rec, err := record.NewRecorder(f, interval, timeout)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	sub := pm.Subscribe("record", writerBuffer, prober.Block)
+	var dropped uint64
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for e := range sub.Events() {
+			// A full queue drops events for every consumer. Stop there rather
+			// than write a recording with a gap, but keep draining.
+			if dropped == 0 {
+				dropped = pm.Dropped()
+			}
+			if dropped == 0 {
+				rec.Write(e)
+			}
+		}
+	}()
+	finish := func() error {
+		<-done
+		err := rec.Err()
+		if err == nil && dropped > 0 {
+			err = fmt.Errorf("recording stopped early: %d probe events were dropped because the event queue was full", dropped)
+		}
+		if cerr := f.Close(); err == nil && cerr != nil {
+			err = fmt.Errorf("failed to close recording: %w", cerr)
+		}
+		return err
+	}
+	return finish, nil
+}
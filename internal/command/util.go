@@ -2,14 +2,29 @@ package command
 
 import (
 	"bufio"
+	"context"
 	"errors"
+	"fmt"
 	"io"
+	"io/fs"
 	"net"
 	"os"
 	"regexp"
 	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"github.com/servak/mping/internal/alert"
+	"github.com/servak/mping/internal/config"
+	"github.com/servak/mping/internal/stats"
 )
 
+// writerBuffer is how many events --record and --store may fall behind by
+// before they hold up the other consumers. Probing is never held up: the
+// event queue absorbs longer stalls.
+const writerBuffer = 10000
+
 func parseCidr(_hosts []string) []string {
 	hosts := []string{}
 	for _, h := range _hosts {
@@ -75,3 +90,49 @@ func parseHostnames(args []string, fpath string) []string {
 	hosts = append(hosts, args...)
 	return parseCidr(hosts)
 }
+
+// loadConfig reads the config file at path. A missing file means the defaults;
+// any other read or validation error is returned.
+func loadConfig(path string) (*config.Config, error) {
+	cfg, err := config.LoadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return config.DefaultConfig(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+func addOutageFlags(flags *pflag.FlagSet) {
+	flags.Int("outage-failures", stats.DefaultOutageFailures, "consecutive failures that start an outage")
+	flags.Int("outage-recoveries", stats.DefaultOutageRecoveries, "consecutive successes that end an outage")
+}
+
+func getOutageThresholds(flags *pflag.FlagSet) (int, int, error) {
+	failures, err := flags.GetInt("outage-failures")
+	if err != nil {
+		return 0, 0, err
+	}
+	recoveries, err := flags.GetInt("outage-recoveries")
+	if err != nil {
+		return 0, 0, err
+	}
+	if failures < 1 || recoveries < 1 {
+		return 0, 0, errors.New("outage-failures and outage-recoveries must be at least 1")
+	}
+	return failures, recoveries, nil
+}
+
+// startAlerts evaluates the alert rules until ctx is done. The returned
+// function waits for running actions and returns how many failed.
+func startAlerts(ctx context.Context, rules []*alert.Rule, mm stats.MetricsProvider, interval time.Duration) func() (int, error) {
+	if len(rules) == 0 {
+		return func() (int, error) { return 0, nil }
+	}
+	engine := alert.NewEngine(rules)
+	// Evaluate at least twice per probe so that short streaks are not missed
+	every := min(max(interval/2, 50*time.Millisecond), time.Second)
+	go engine.Run(ctx, mm, every)
+	return engine.Wait
+}
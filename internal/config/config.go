@@ -10,6 +10,7 @@ import (
 
 	"gopkg.in/yaml.v3"
 
+	"github.com/servak/mping/internal/alert"
 	"github.com/servak/mping/internal/prober"
 	"github.com/servak/mping/internal/ui/shared"
 )
@@ -50,6 +51,7 @@ type Config struct {
 	Prober  map[string]*prober.ProberConfig `yaml:"prober"`
 	Default string                          `yaml:"default"`
 	UI      *shared.Config                  `yaml:"ui"`
+	Alerts  []*alert.Rule                   `yaml:"alerts,omitempty"`
 }
 
 func (c *Config) SetTitle(t string) {
@@ -62,6 +64,59 @@ func (c *Config) SetSourceInterface(sourceInterface string) {
 			if prober.ICMP != nil {
 				prober.ICMP.SourceInterface = sourceInterface
 			}
+			if prober.TCP != nil {
+				prober.TCP.SourceInterface = sourceInterface
+			}
+			if prober.HTTP != nil {
+				prober.HTTP.SourceInterface = sourceInterface
+			}
+			if prober.DNS != nil {
+				prober.DNS.SourceInterface = sourceInterface
+			}
+			if prober.NTP != nil {
+				prober.NTP.SourceInterface = sourceInterface
+			}
+		}
+	}
+}
+
+// SetBindToDevice binds all probers' sockets to a network device or VRF (Linux only)
+func (c *Config) SetBindToDevice(device string) {
+	if device != "" {
+		for _, prober := range c.Prober {
+			if prober.ICMP != nil {
+				prober.ICMP.BindToDevice = device
+			}
+			if prober.TCP != nil {
+				prober.TCP.BindToDevice = device
+			}
+			if prober.HTTP != nil {
+				prober.HTTP.BindToDevice = device
+			}
+			if prober.DNS != nil {
+				prober.DNS.BindToDevice = device
+			}
+			if prober.NTP != nil {
+				prober.NTP.BindToDevice = device
+			}
+		}
+	}
+}
+
+// SetResolveInterval enables periodic re-resolution of target hostnames
+// for all probers that resolve names up front (ICMP, TCP and DNS)
+func (c *Config) SetResolveInterval(d time.Duration) {
+	if d > 0 {
+		for _, prober := range c.Prober {
+			if prober.ICMP != nil {
+				prober.ICMP.ResolveInterval = d
+			}
+			if prober.TCP != nil {
+				prober.TCP.ResolveInterval = d
+			}
+			if prober.DNS != nil {
+				prober.DNS.ResolveInterval = d
+			}
 		}
 	}
 }
@@ -84,6 +139,17 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	// Validate alert rules, whose names identify their state
+	names := make(map[string]bool)
+	for i, rule := range c.Alerts {
+		if err := rule.Validate(); err != nil {
+			ve.Add(fmt.Errorf("alert %d (%s): %w", i+1, rule.Name, err))
+		} else if names[rule.Name] {
+			ve.Add(fmt.Errorf("alert %d: duplicate name '%s'", i+1, rule.Name))
+		}
+		names[rule.Name] = true
+	}
+
 	if ve.HasErrors() {
 		return ve
 	}
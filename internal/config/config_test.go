@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/servak/mping/internal/prober"
 )
@@ -227,4 +228,94 @@ prober:
 			t.Errorf("Expected DNS server validation error, got: %v", err)
 		}
 	})
-}
\ No newline at end of file
+}
+func TestSetSourceInterface(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.SetSourceInterface("eth1")
+
+	for name, p := range cfg.Prober {
+		var got string
+		switch {
+		case p.ICMP != nil:
+			got = p.ICMP.SourceInterface
+		case p.TCP != nil:
+			got = p.TCP.SourceInterface
+		case p.HTTP != nil:
+			got = p.HTTP.SourceInterface
+		case p.DNS != nil:
+			got = p.DNS.SourceInterface
+		case p.NTP != nil:
+			got = p.NTP.SourceInterface
+		default:
+			continue
+		}
+		if got != "eth1" {
+			t.Errorf("prober %s: source interface = %q, want eth1", name, got)
+		}
+	}
+}
+
+func TestSetBindToDevice(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.SetBindToDevice("vrf-blue")
+
+	for name, p := range cfg.Prober {
+		var got string
+		switch {
+		case p.ICMP != nil:
+			got = p.ICMP.BindToDevice
+		case p.TCP != nil:
+			got = p.TCP.BindToDevice
+		case p.HTTP != nil:
+			got = p.HTTP.BindToDevice
+		case p.DNS != nil:
+			got = p.DNS.BindToDevice
+		case p.NTP != nil:
+			got = p.NTP.BindToDevice
+		default:
+			continue
+		}
+		if got != "vrf-blue" {
+			t.Errorf("prober %s: bind device = %q, want vrf-blue", name, got)
+		}
+	}
+}
+
+func TestLoadAlerts(t *testing.T) {
+	cfg, err := Load(`
+alerts:
+  - name: down
+    down_after: 3
+    up_after: 2
+    targets: ["192.0.2.*"]
+    exec: notify-send "$MPING_TARGET is $MPING_STATE"
+  - name: slow
+    rtt_above: 200ms
+    window: 5m
+    webhook: https://hooks.example.com/mping
+`)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(cfg.Alerts) != 2 {
+		t.Fatalf("Expected 2 alert rules, got %d", len(cfg.Alerts))
+	}
+	if r := cfg.Alerts[1]; r.RTTAbove != 200*time.Millisecond || r.Window != 5*time.Minute {
+		t.Errorf("Durations not parsed: %+v", r)
+	}
+
+	_, err = Load(`
+alerts:
+  - name: down
+    down_after: 3
+    exec: "true"
+  - name: down
+    loss_above: 10
+    exec: "true"
+  - name: lossy
+    loss_above: 10
+`)
+	if err == nil || !strings.Contains(err.Error(), "exec or webhook is required") || !strings.Contains(err.Error(), "duplicate name 'down'") {
+		t.Errorf("Expected validation errors, got %v", err)
+	}
+}
@@ -0,0 +1,84 @@
+//go:build linux
+
+package prober
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+)
+
+// bindToDevice returns a socket control function that sets SO_BINDTODEVICE, so
+// packets leave through device (an interface or VRF) regardless of routing
+func bindToDevice(device string) func(network, address string, c syscall.RawConn) error {
+	return func(network, address string, c syscall.RawConn) error {
+		var serr error
+		if err := c.Control(func(fd uintptr) {
+			serr = setBindToDevice(int(fd), device)
+		}); err != nil {
+			return err
+		}
+		return serr
+	}
+}
+
+func setBindToDevice(fd int, device string) error {
+	if err := syscall.SetsockoptString(fd, syscall.SOL_SOCKET, syscall.SO_BINDTODEVICE, device); err != nil {
+		return fmt.Errorf("failed to bind to device %s: %w", device, os.NewSyscallError("setsockopt", err))
+	}
+	return nil
+}
+
+// listenICMPDevice opens an ICMP endpoint bound to device. Datagram endpoints
+// ("udp4"/"udp6") are ICMP sockets rather than UDP, so they are created directly.
+func listenICMPDevice(network, address, device string) (net.PacketConn, error) {
+	var family, proto int
+	switch network {
+	case "udp4":
+		family, proto = syscall.AF_INET, syscall.IPPROTO_ICMP
+	case "udp6":
+		family, proto = syscall.AF_INET6, syscall.IPPROTO_ICMPV6
+	default:
+		lc := net.ListenConfig{Control: bindToDevice(device)}
+		return lc.ListenPacket(context.Background(), network, address)
+	}
+
+	var ip net.IP
+	if address != "" {
+		if ip = net.ParseIP(address); ip == nil {
+			return nil, fmt.Errorf("invalid source address: %s", address)
+		}
+	}
+	var sa syscall.Sockaddr
+	if family == syscall.AF_INET {
+		sa4 := &syscall.SockaddrInet4{}
+		if ip != nil {
+			copy(sa4.Addr[:], ip.To4())
+		}
+		sa = sa4
+	} else {
+		sa6 := &syscall.SockaddrInet6{}
+		if ip != nil {
+			copy(sa6.Addr[:], ip.To16())
+		}
+		sa = sa6
+	}
+
+	s, err := syscall.Socket(family, syscall.SOCK_DGRAM|syscall.SOCK_CLOEXEC, proto)
+	if err != nil {
+		return nil, os.NewSyscallError("socket", err)
+	}
+	if err := setBindToDevice(s, device); err != nil {
+		syscall.Close(s)
+		return nil, err
+	}
+	if err := syscall.Bind(s, sa); err != nil {
+		syscall.Close(s)
+		return nil, os.NewSyscallError("bind", err)
+	}
+	f := os.NewFile(uintptr(s), "datagram-oriented icmp")
+	defer f.Close()
+	return net.FilePacketConn(f)
+}
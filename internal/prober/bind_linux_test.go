@@ -0,0 +1,65 @@
+//go:build linux
+
+package prober
+
+import (
+	"errors"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestBindToDevice(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			c.Close()
+		}
+	}()
+
+	d, err := newDialer("tcp", "", "lo", time.Second)
+	if err != nil {
+		t.Fatalf("newDialer failed: %v", err)
+	}
+	conn, err := d.Dial("tcp", ln.Addr().String())
+	if errors.Is(err, os.ErrPermission) {
+		t.Skip("SO_BINDTODEVICE not permitted")
+	}
+	if err != nil {
+		t.Fatalf("dial bound to lo failed: %v", err)
+	}
+	conn.Close()
+
+	d, _ = newDialer("tcp", "", "nonexistent-if0", time.Second)
+	if _, err := d.Dial("tcp", ln.Addr().String()); err == nil {
+		t.Error("expected error binding to unknown device")
+	}
+}
+
+func TestListenICMPDevice(t *testing.T) {
+	opened := false
+	for _, network := range []string{"ip4:icmp", "udp4"} {
+		c, err := listenICMPDevice(network, "127.0.0.1", "lo")
+		if err != nil {
+			t.Logf("%s: %v", network, err)
+			continue
+		}
+		opened = true
+		if ip := peerIP(c.LocalAddr()); ip != "127.0.0.1" {
+			t.Errorf("%s: local address = %s, want 127.0.0.1", network, ip)
+		}
+		c.Close()
+	}
+	if !opened {
+		t.Skip("cannot open ICMP sockets")
+	}
+}
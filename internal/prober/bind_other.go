@@ -0,0 +1,21 @@
+//go:build !linux
+
+package prober
+
+import (
+	"errors"
+	"net"
+	"syscall"
+)
+
+var errBindToDeviceUnsupported = errors.New("bind_to_device is only supported on Linux")
+
+func bindToDevice(device string) func(network, address string, c syscall.RawConn) error {
+	return func(network, address string, c syscall.RawConn) error {
+		return errBindToDeviceUnsupported
+	}
+}
+
+func listenICMPDevice(network, address, device string) (net.PacketConn, error) {
+	return nil, errBindToDeviceUnsupported
+}
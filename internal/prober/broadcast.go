@@ -0,0 +1,182 @@
+package prober
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// QueueStatus reports how far event delivery is behind the probers
+type QueueStatus interface {
+	QueueDepth() int
+	Dropped() uint64
+}
+
+// DeliveryPolicy decides what happens to an event when a subscriber's buffer is full
+type DeliveryPolicy int
+
+const (
+	// Block waits until the subscriber has room, which holds up every other subscriber
+	Block DeliveryPolicy = iota
+	// Drop discards the event for that subscriber and counts it as dropped
+	Drop
+)
+
+// Subscription receives the events of a Broadcaster
+type Subscription struct {
+	name    string
+	ch      chan *Event
+	policy  DeliveryPolicy
+	dropped atomic.Uint64
+}
+
+// Events returns the channel the subscription's events are delivered on. It
+// is closed when the broadcaster's input is closed.
+func (s *Subscription) Events() <-chan *Event {
+	return s.ch
+}
+
+func (s *Subscription) Name() string {
+	return s.name
+}
+
+// Dropped returns how many events were discarded because the buffer was full
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+func (s *Subscription) deliver(e *Event) {
+	if s.policy == Block {
+		s.ch <- e
+		return
+	}
+	select {
+	case s.ch <- e:
+	default:
+		s.dropped.Add(1)
+	}
+}
+
+// Broadcaster delivers every event of one channel to any number of
+// subscribers. Events are read from the channel as soon as they are sent and
+// queued, so that probers never wait for a slow subscriber; once the queue
+// holds limit events, further events are dropped until it drains.
+type Broadcaster struct {
+	in       <-chan *Event
+	limit    int
+	mu       sync.Mutex
+	queue    []*Event
+	queued   atomic.Int64 // Events read but not yet delivered to every subscriber
+	overflow atomic.Uint64
+	wake     chan struct{}
+	subs     []*Subscription
+	started  bool
+	inClosed bool
+	closed   bool
+}
+
+// NewBroadcaster creates a broadcaster of in that queues up to limit events
+func NewBroadcaster(in <-chan *Event, limit int) *Broadcaster {
+	return &Broadcaster{in: in, limit: limit, wake: make(chan struct{}, 1)}
+}
+
+// Subscribe adds a subscriber with a buffer of the given size. Subscribers
+// only receive events delivered after they subscribed, so subscribe before
+// Start to receive every event.
+func (b *Broadcaster) Subscribe(name string, buffer int, policy DeliveryPolicy) *Subscription {
+	s := &Subscription{name: name, ch: make(chan *Event, buffer), policy: policy}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		close(s.ch)
+	}
+	b.subs = append(b.subs, s)
+	return s
+}
+
+// Start delivers events in the background until the input channel is closed
+// and the queue is empty, then closes every subscription. Calling Start again
+// has no effect.
+func (b *Broadcaster) Start() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.started {
+		return
+	}
+	b.started = true
+	go b.read()
+	go b.dispatch()
+}
+
+// read moves events from the input channel to the queue
+func (b *Broadcaster) read() {
+	for e := range b.in {
+		b.mu.Lock()
+		if len(b.queue) < b.limit {
+			b.queue = append(b.queue, e)
+			b.queued.Add(1)
+		} else {
+			b.overflow.Add(1)
+		}
+		b.mu.Unlock()
+		b.signal()
+	}
+	b.mu.Lock()
+	b.inClosed = true
+	b.mu.Unlock()
+	b.signal()
+}
+
+func (b *Broadcaster) signal() {
+	select {
+	case b.wake <- struct{}{}:
+	default:
+	}
+}
+
+// dispatch delivers the queued events to the subscribers
+func (b *Broadcaster) dispatch() {
+	for {
+		b.mu.Lock()
+		batch, subs, done := b.queue, b.subs, b.inClosed
+		b.queue = nil
+		if len(batch) == 0 && done {
+			b.closed = true
+			for _, s := range b.subs {
+				close(s.ch)
+			}
+			b.mu.Unlock()
+			return
+		}
+		b.mu.Unlock()
+
+		if len(batch) == 0 {
+			<-b.wake
+			continue
+		}
+		for _, e := range batch {
+			for _, s := range subs {
+				s.deliver(e)
+			}
+			b.queued.Add(-1)
+		}
+	}
+}
+
+// QueueDepth returns the number of events sent by the probers that have not
+// been delivered to every subscriber yet
+func (b *Broadcaster) QueueDepth() int {
+	return int(b.queued.Load()) + len(b.in)
+}
+
+// Dropped returns the number of events dropped because the queue was full or
+// a subscriber with the Drop policy fell behind
+func (b *Broadcaster) Dropped() uint64 {
+	b.mu.Lock()
+	subs := b.subs
+	b.mu.Unlock()
+	n := b.overflow.Load()
+	for _, s := range subs {
+		n += s.Dropped()
+	}
+	return n
+}
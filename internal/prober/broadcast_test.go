@@ -0,0 +1,67 @@
+package prober
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBroadcaster(t *testing.T) {
+	in := make(chan *Event, 10)
+	b := NewBroadcaster(in, 100)
+	first := b.Subscribe("first", 10, Block)
+	second := b.Subscribe("second", 10, Block)
+	b.Start()
+	b.Start()
+
+	for i := 0; i < 3; i++ {
+		in <- &Event{Key: "a", Result: SENT}
+	}
+	close(in)
+	for _, s := range []*Subscription{first, second} {
+		var n int
+		for range s.Events() {
+			n++
+		}
+		if n != 3 {
+			t.Errorf("Expected %s to receive every event, got %d", s.Name(), n)
+		}
+	}
+
+	// Subscribing after the input was closed returns a closed subscription
+	if _, ok := <-b.Subscribe("late", 1, Block).Events(); ok {
+		t.Error("Expected a closed subscription")
+	}
+}
+
+func TestBroadcasterDropPolicy(t *testing.T) {
+	in := make(chan *Event)
+	b := NewBroadcaster(in, 100)
+	slow := b.Subscribe("slow", 2, Drop)
+	fast := b.Subscribe("fast", 0, Block) // Delivered to last, so receiving from it means slow was done
+	b.Start()
+
+	// The slow subscriber never reads, which must not hold up the fast one
+	for i := 0; i < 5; i++ {
+		select {
+		case in <- &Event{Key: "a", Result: SENT}:
+		case <-time.After(time.Second):
+			t.Fatal("Broadcaster blocked on a subscriber with the Drop policy")
+		}
+		<-fast.Events()
+	}
+	close(in)
+
+	if slow.Dropped() != 3 || b.Dropped() != 3 {
+		t.Errorf("Expected 3 dropped events, got %d (total %d)", slow.Dropped(), b.Dropped())
+	}
+	var n int
+	for range slow.Events() {
+		n++
+	}
+	if n != 2 {
+		t.Errorf("Expected the buffered events to be delivered, got %d", n)
+	}
+	if fast.Dropped() != 0 {
+		t.Errorf("Expected no drops with the Block policy, got %d", fast.Dropped())
+	}
+}
@@ -1,6 +1,6 @@
 package prober
 
-// Probe detail information (TargetIP and TCPDetails removed)
+// Probe detail information (TargetIP removed)
 type ProbeDetails struct {
 	ProbeType string `json:"probe_type"`
 
@@ -9,7 +9,7 @@ type ProbeDetails struct {
 	HTTP *HTTPDetails `json:"http,omitempty"`
 	DNS  *DNSDetails  `json:"dns,omitempty"`
 	NTP  *NTPDetails  `json:"ntp,omitempty"`
-	// TCP has no detailed information (only connection availability)
+	TCP  *TCPDetails  `json:"tcp,omitempty"`
 }
 
 type ICMPDetails struct {
@@ -18,7 +18,8 @@ type ICMPDetails struct {
 	ICMPType   int    `json:"icmp_type"`
 	ICMPCode   int    `json:"icmp_code"`
 	Checksum   uint16 `json:"checksum"`
-	Payload    string `json:"payload"` // Actual payload content with length limit
+	Payload    string `json:"payload"`        // Actual payload content with length limit
+	From       string `json:"from,omitempty"` // Router that reported an ICMP error
 }
 
 type HTTPDetails struct {
@@ -45,4 +46,14 @@ type NTPDetails struct {
 	Stratum   int    `json:"stratum"`
 	Offset    int64  `json:"offset_microseconds"` // In microseconds
 	Precision int    `json:"precision"`
-}
\ No newline at end of file
+}
+
+type TCPDetails struct {
+	Method      string `json:"method"`                 // "connect" or "syn" (half-open)
+	RemoteAddr  string `json:"remote_addr"`            // Resolved ip:port that was dialed
+	LocalAddr   string `json:"local_addr,omitempty"`   // Local ip:port of the connection
+	ConnectTime int64  `json:"connect_microseconds"`   // TCP handshake time, in microseconds
+	FailureKind string `json:"failure_kind,omitempty"` // "refused", "timeout", "no_route", "banner_mismatch" or "error"
+	Banner      string `json:"banner,omitempty"`       // Data read after connect, with length limit
+	BannerTime  int64  `json:"banner_microseconds,omitempty"`
+}
@@ -25,6 +25,8 @@ type DNSTarget struct {
 	UseTCP         bool
 	ServerIP       string // Pre-resolved server IP
 	OriginalTarget string // Original target string for display
+
+	serverName *resolvedName // Server hostname tracked for re-resolution
 }
 
 type (
@@ -34,6 +36,8 @@ type (
 		prefix   string
 		exitChan chan bool
 		wg       sync.WaitGroup
+		mu       sync.Mutex // Protects DNSTarget.ServerIP
+		lookupMu sync.Mutex
 	}
 
 	DNSConfig struct {
@@ -43,6 +47,11 @@ type (
 		UseTCP           bool   `yaml:"use_tcp,omitempty"`
 		RecursionDesired bool   `yaml:"recursion_desired,omitempty"`
 		ExpectCodes      string `yaml:"expect_codes"` // DNS response codes: "0", "0-5", "0,2,3"
+
+		ResolveInterval time.Duration `yaml:"resolve_interval,omitempty"` // Re-resolve server hostnames periodically (0 disables)
+		ResolveAll      bool          `yaml:"resolve_all,omitempty"`      // Track all A/AAAA records, not only the queried one
+		SourceInterface string        `yaml:"source_interface,omitempty"` // Interface name or IP address to send from
+		BindToDevice    string        `yaml:"bind_to_device,omitempty"`   // Send through this interface or VRF (Linux SO_BINDTODEVICE)
 	}
 )
 
@@ -69,6 +78,10 @@ func (cfg *DNSConfig) Validate() error {
 		}
 	}
 
+	if cfg.ResolveInterval < 0 {
+		return fmt.Errorf("invalid resolve_interval: %v (must not be negative)", cfg.ResolveInterval)
+	}
+
 	return nil
 }
 
@@ -101,6 +114,12 @@ func (p *DNSProber) Accept(target string) error {
 
 	// Store complete DNSTarget with pre-resolved IP
 	dnsTarget.ServerIP = serverIP.String()
+	if p.config.ResolveInterval > 0 && !isIPLiteral(dnsTarget.Server) {
+		dnsTarget.serverName = newResolvedName(dnsTarget.Server, "ip", dnsTarget.ServerIP, p.config.ResolveAll)
+		if p.config.ResolveAll {
+			dnsTarget.serverName.refresh() // Record the full set as the baseline
+		}
+	}
 	p.targets = append(p.targets, dnsTarget)
 
 	// Unique and sorted targets
@@ -177,9 +196,49 @@ func (p *DNSProber) emitRegistrationEvents(r chan *Event) {
 	}
 }
 
+// reresolve looks up tracked server hostnames again and switches targets
+// whose server address changed
+func (p *DNSProber) reresolve(r chan *Event) {
+	if !p.lookupMu.TryLock() {
+		return // Previous lookup still running
+	}
+	defer p.lookupMu.Unlock()
+
+	for _, target := range p.targets {
+		if target.serverName == nil {
+			continue
+		}
+		select {
+		case <-p.exitChan:
+			return // Stopping; skip the remaining lookups
+		default:
+		}
+		msg, err := target.serverName.refresh()
+		if err != nil || msg == "" {
+			continue // Keep querying the last known address
+		}
+		p.mu.Lock()
+		target.ServerIP = target.serverName.current
+		p.mu.Unlock()
+		r <- &Event{
+			Key:         target.OriginalTarget,
+			DisplayName: target.OriginalTarget,
+			Result:      RESOLVED,
+			SentTime:    time.Now(),
+			Message:     msg,
+		}
+	}
+}
+
 func (p *DNSProber) Start(result chan *Event, interval, timeout time.Duration) error {
 	p.emitRegistrationEvents(result)
 	ticker := time.NewTicker(interval)
+	var resolveC <-chan time.Time
+	if p.config.ResolveInterval > 0 {
+		resolveTicker := time.NewTicker(p.config.ResolveInterval)
+		defer resolveTicker.Stop()
+		resolveC = resolveTicker.C
+	}
 	p.wg.Add(1)
 	go func() {
 		defer p.wg.Done()
@@ -195,6 +254,13 @@ func (p *DNSProber) Start(result chan *Event, interval, timeout time.Duration) e
 				for _, target := range p.targets {
 					go p.sendProbe(result, target, timeout)
 				}
+			case <-resolveC:
+				// Tracked so that no RESOLVED event is sent after Start returns
+				p.wg.Add(1)
+				go func() {
+					defer p.wg.Done()
+					p.reresolve(result)
+				}()
 			}
 		}
 	}()
@@ -217,9 +283,17 @@ func (p *DNSProber) sendProbe(result chan *Event, target *DNSTarget, timeout tim
 	// Create DNS client
 	c := new(dns.Client)
 	c.Timeout = timeout
+	network := "udp"
 	if target.UseTCP {
 		c.Net = "tcp"
+		network = "tcp"
+	}
+	dialer, err := newDialer(network, p.config.SourceInterface, p.config.BindToDevice, timeout)
+	if err != nil {
+		p.failed(result, target, now, err)
+		return
 	}
+	c.Dialer = dialer
 
 	// Create DNS query
 	m := new(dns.Msg)
@@ -233,7 +307,9 @@ func (p *DNSProber) sendProbe(result chan *Event, target *DNSTarget, timeout tim
 	m.RecursionDesired = p.config.RecursionDesired
 
 	// Send DNS query using pre-resolved server IP
+	p.mu.Lock()
 	server := fmt.Sprintf("%s:%d", target.ServerIP, target.Port)
+	p.mu.Unlock()
 	r, rtt, err := c.Exchange(m, server)
 	if err != nil {
 		p.failed(result, target, now, err)
@@ -1,6 +1,7 @@
 package prober
 
 import (
+	"context"
 	"crypto/tls"
 	"errors"
 	"fmt"
@@ -36,6 +37,9 @@ type (
 		ExpectBody  string      `yaml:"expect_body,omitempty"`
 		TLS         *TLSConfig  `yaml:"tls,omitempty"`
 		RedirectOFF bool        `yaml:"redirect_off,omitempty"`
+
+		SourceInterface string `yaml:"source_interface,omitempty"` // Interface name or IP address to send from
+		BindToDevice    string `yaml:"bind_to_device,omitempty"`   // Send through this interface or VRF (Linux SO_BINDTODEVICE)
 	}
 
 	TLSConfig struct {
@@ -66,6 +70,7 @@ func NewHTTPProber(cfg *HTTPConfig, prefix string) *HTTPProber {
 		Transport: &customTransport{
 			transport: &http.Transport{
 				TLSClientConfig: &tls.Config{InsecureSkipVerify: skipVerify},
+				DialContext:     sourceDialContext(cfg.SourceInterface, cfg.BindToDevice),
 			},
 			headers: cfg.Header,
 		},
@@ -289,3 +294,14 @@ func (p *HTTPProber) isExpectedStatusCode(statusCode int) bool {
 	// Default: accept any status code when no expectation is configured
 	return true
 }
+
+// sourceDialContext returns a dial function that binds connections to sourceInterface and device
+func sourceDialContext(sourceInterface, device string) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		d, err := newDialer(network, sourceInterface, device, 0)
+		if err != nil {
+			return nil, err
+		}
+		return d.DialContext(ctx, network, addr)
+	}
+}
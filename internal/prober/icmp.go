@@ -2,6 +2,7 @@ package prober
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"net"
 	"os"
@@ -19,19 +20,33 @@ const (
 	ICMPV6 ProbeType = "icmpv6"
 )
 
+// lateReplyWindow is how long after timing out a request a reply is still
+// recognized as late rather than ignored
+const lateReplyWindow = 30 * time.Second
+
 type (
 	ICMPProber struct {
 		version  ProbeType
 		prefix   string // Custom prefix like "my-ping", "icmpv4", etc.
-		c        *icmp.PacketConn
+		config   *ICMPConfig
+		c        net.PacketConn
+		datagram bool // c is an unprivileged datagram (udp4/udp6) socket
 		body     []byte
-		targets  map[string]string // IPAddr string -> DisplayName
+		targets  map[string]string        // IPAddr string -> DisplayName
+		keys     map[string]string        // IPAddr string -> Key, for re-resolved targets whose key is the original address
+		names    map[string]*resolvedName // Key -> hostname tracked for re-resolution
+		groups   map[string]string        // IPAddr string -> hostname, for names expanded to all addresses
+		families map[string]string        // IPAddr string -> "v4"/"v6", for dual-stack comparison targets
+		lookupMu sync.Mutex
 		timeout  time.Duration
 		runCnt   int
 		runID    int
-		tables   map[runTime]map[string]bool
+		tables   map[runTime]map[string]*echoState
+		late     map[runTime]map[string]*echoState // Timed-out rounds, kept to recognize late replies
+		lastSeq  map[string]int                    // IPAddr string -> sequence of the newest reply, to detect reordering
 		mu       sync.Mutex
 		exitChan chan bool
+		stopOnce sync.Once
 		wg       sync.WaitGroup
 	}
 
@@ -40,12 +55,25 @@ type (
 		TOS             int    `yaml:"tos,omitempty"`
 		TTL             int    `yaml:"ttl,omitempty"`
 		SourceInterface string `yaml:"source_interface,omitempty"`
+		Unprivileged    bool   `yaml:"unprivileged,omitempty"`   // Use datagram sockets (net.ipv4.ping_group_range); ICMP errors then show as timeouts
+		BindToDevice    string `yaml:"bind_to_device,omitempty"` // Send through this interface or VRF (Linux SO_BINDTODEVICE)
+
+		ResolveInterval time.Duration `yaml:"resolve_interval,omitempty"` // Re-resolve hostnames periodically (0 disables)
+		ResolveAll      bool          `yaml:"resolve_all,omitempty"`      // Track all A/AAAA records, not only the probed one
+		AllAddresses    bool          `yaml:"all_addresses,omitempty"`    // Probe every resolved address as its own target
 	}
 
 	runTime struct {
 		runCnt   int
 		sentTime time.Time
 	}
+
+	// echoState tracks the echo request sent to one address in a round
+	echoState struct {
+		sentTime time.Time // When the request was written, for per-packet RTT
+		done     bool      // Answered, failed or timed out
+		replied  bool      // An echo reply was received; further replies are duplicates
+	}
 )
 
 // Validate validates the ICMP configuration
@@ -57,47 +85,104 @@ func (cfg *ICMPConfig) Validate() error {
 	if cfg.TTL < 0 || cfg.TTL > 255 {
 		return fmt.Errorf("invalid TTL value: %d (must be 0-255)", cfg.TTL)
 	}
+	if cfg.ResolveInterval < 0 {
+		return fmt.Errorf("invalid resolve_interval: %v (must not be negative)", cfg.ResolveInterval)
+	}
 	return nil
 }
 
 func NewICMPProber(t ProbeType, cfg *ICMPConfig, prefix string) (*ICMPProber, error) {
-	var (
-		c   *icmp.PacketConn
-		err error
-	)
+	c, datagram, err := openICMPConn(t, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &ICMPProber{
+		version:  t,
+		prefix:   prefix,
+		config:   cfg,
+		c:        c,
+		datagram: datagram,
+		tables:   make(map[runTime]map[string]*echoState),
+		late:     make(map[runTime]map[string]*echoState),
+		lastSeq:  make(map[string]int),
+		targets:  make(map[string]string),
+		keys:     make(map[string]string),
+		names:    make(map[string]*resolvedName),
+		groups:   make(map[string]string),
+		families: make(map[string]string),
+		runID:    os.Getpid() & 0xffff,
+		runCnt:   0,
+		body:     []byte(cfg.Body),
+		exitChan: make(chan bool),
+	}, nil
+}
 
+// openICMPConn opens the ICMP socket for the given version and applies TOS/TTL settings.
+// A raw socket is used unless cfg.Unprivileged is set; if opening the raw socket is
+// not permitted, it falls back to an unprivileged datagram socket. The returned bool
+// reports whether the socket is a datagram socket.
+func openICMPConn(t ProbeType, cfg *ICMPConfig) (net.PacketConn, bool, error) {
 	// Resolve source interface to IP address if specified
 	sourceAddr, err := resolveSourceInterface(cfg.SourceInterface, t)
 	if err != nil {
-		return nil, fmt.Errorf("failed to resolve source interface: %v", err)
+		return nil, false, fmt.Errorf("failed to resolve source interface: %v", err)
 	}
 
-	if t == ICMPV4 {
-		c, err = icmp.ListenPacket("ip4:icmp", sourceAddr)
-		if err != nil {
-			return nil, err
+	rawNetwork, dgramNetwork := "ip4:icmp", "udp4"
+	if t == ICMPV6 {
+		rawNetwork, dgramNetwork = "ip6:ipv6-icmp", "udp6"
+	}
+
+	datagram := cfg.Unprivileged
+	var c net.PacketConn
+	if datagram {
+		c, err = listenICMPSocket(dgramNetwork, sourceAddr, cfg.BindToDevice)
+	} else {
+		c, err = listenICMPSocket(rawNetwork, sourceAddr, cfg.BindToDevice)
+		if err != nil && errors.Is(err, os.ErrPermission) {
+			// No CAP_NET_RAW; try unprivileged ICMP (Linux net.ipv4.ping_group_range)
+			dc, dErr := listenICMPSocket(dgramNetwork, sourceAddr, cfg.BindToDevice)
+			if dErr != nil {
+				return nil, false, fmt.Errorf("%w (unprivileged fallback failed: %v)", err, dErr)
+			}
+			c, err, datagram = dc, nil, true
+		}
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	if t == ICMPV4 && (cfg.TOS != 0 || cfg.TTL != 0) {
+		var p *ipv4.PacketConn
+		if ic, ok := c.(*icmp.PacketConn); ok {
+			p = ic.IPv4PacketConn()
+		} else {
+			p = ipv4.NewPacketConn(c)
 		}
-		p := c.IPv4PacketConn()
 		if cfg.TOS != 0 {
 			p.SetTOS(cfg.TOS)
 		}
 		if cfg.TTL != 0 {
 			p.SetTTL(cfg.TTL)
 		}
-	} else {
-		c, err = icmp.ListenPacket("ip6:ipv6-icmp", sourceAddr)
 	}
-	return &ICMPProber{
-		version:  t,
-		prefix:   prefix,
-		c:        c,
-		tables:   make(map[runTime]map[string]bool),
-		targets:  make(map[string]string),
-		runID:    os.Getpid() & 0xffff,
-		runCnt:   0,
-		body:     []byte(cfg.Body),
-		exitChan: make(chan bool),
-	}, err
+	return c, datagram, nil
+}
+
+
+// Reopen closes the current ICMP socket and opens a new one with the same settings
+func (p *ICMPProber) Reopen() error {
+	c, datagram, err := openICMPConn(p.version, p.config)
+	if err != nil {
+		return err
+	}
+	old := p.c
+	p.c = c
+	p.datagram = datagram
+	if old != nil {
+		old.Close()
+	}
+	return nil
 }
 
 func (p *ICMPProber) Accept(target string) error {
@@ -119,6 +204,12 @@ func (p *ICMPProber) Accept(target string) error {
 		resolvType = "ip6"
 	}
 
+	hostname, dual := cutDualStack(hostname)
+	hostname, all := cutAllAddresses(hostname)
+	if !dual && (all || p.config.AllAddresses) && net.ParseIP(hostname) == nil {
+		return p.acceptAll(hostname, resolvType)
+	}
+
 	// Resolve hostname to IP address
 	ip, err := net.ResolveIPAddr(resolvType, hostname)
 	if err != nil {
@@ -139,31 +230,96 @@ func (p *ICMPProber) Accept(target string) error {
 
 	// Store IP address string with display name
 	p.targets[ipStr] = displayName
+	if dual {
+		p.groups[ipStr] = dualStackPrefix + hostname
+		p.families[ipStr] = "v4"
+		if p.version == ICMPV6 {
+			p.families[ipStr] = "v6"
+		}
+	}
 
+	// Track the hostname so address changes can be followed
+	if p.config.ResolveInterval > 0 && net.ParseIP(hostname) == nil {
+		n := newResolvedName(hostname, resolvType, ipStr, p.config.ResolveAll)
+		if p.config.ResolveAll {
+			n.refresh() // Record the full set as the baseline
+		}
+		p.names[ipStr] = n
+	}
+
+	return nil
+}
+
+// acceptAll adds one target per address of hostname, grouped under the hostname.
+// Expanded targets are not re-resolved.
+func (p *ICMPProber) acceptAll(hostname, resolvType string) error {
+	addrs, err := lookupAll(resolvType, hostname)
+	if err != nil {
+		return err
+	}
+	for _, ipStr := range addrs {
+		if _, exists := p.targets[ipStr]; exists {
+			continue
+		}
+		p.targets[ipStr] = fmt.Sprintf("%s(%s)", hostname, ipStr)
+		p.groups[ipStr] = hostname
+	}
 	return nil
 }
 
 func (p *ICMPProber) addTable(runCnt int, sentTime time.Time) {
 	rt := runTime{runCnt: runCnt, sentTime: sentTime}
-	addrMap := make(map[string]bool, len(p.targets))
+	p.mu.Lock()
+	addrMap := make(map[string]*echoState, len(p.targets))
 	for ipStr := range p.targets {
-		addrMap[ipStr] = false
+		addrMap[ipStr] = &echoState{sentTime: sentTime}
 	}
-	p.mu.Lock()
 	p.tables[rt] = addrMap
 	p.mu.Unlock()
 }
 
-// getTargetInfo returns Key and DisplayName for the given IP address
+// markSent records the send time of the echo request to addr in round runCnt
+func (p *ICMPProber) markSent(runCnt int, addr string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for k, table := range p.tables {
+		if k.runCnt == runCnt {
+			if state, ok := table[addr]; ok {
+				state.sentTime = time.Now()
+			}
+			return
+		}
+	}
+}
+
+// getTargetInfo returns Key and DisplayName for the given IP address.
+// Callers must hold p.mu.
 func (p *ICMPProber) getTargetInfo(addr string) (string, string) {
+	key := addr
+	if k, exists := p.keys[addr]; exists {
+		key = k
+	}
 	if displayName, exists := p.targets[addr]; exists {
-		return addr, displayName
+		return key, displayName
 	}
-	return addr, addr // fallback
+	return key, addr // fallback
+}
+
+// targetAddrs returns a snapshot of the probed IP addresses
+func (p *ICMPProber) targetAddrs() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	addrs := make([]string, 0, len(p.targets))
+	for ipStr := range p.targets {
+		addrs = append(addrs, ipStr)
+	}
+	return addrs
 }
 
 func (p *ICMPProber) sent(r chan *Event, addr string) {
+	p.mu.Lock()
 	key, displayName := p.getTargetInfo(addr)
+	p.mu.Unlock()
 	r <- &Event{
 		Key:         key,
 		DisplayName: displayName,
@@ -171,45 +327,107 @@ func (p *ICMPProber) sent(r chan *Event, addr string) {
 	}
 }
 
-func (p *ICMPProber) success(r chan *Event, runCnt int, addr string, payload icmp.Message, packetData []byte, packetSize int) {
+// success reports an echo reply received at recvTime. RTT is measured from the
+// request's own send time, so it is not inflated by lock or scheduling delays.
+// Replies to already answered or timed-out requests are reported as DUPLICATE or LATE.
+func (p *ICMPProber) success(r chan *Event, runCnt int, addr string, recvTime time.Time, payload icmp.Message, packetData []byte, packetSize int) {
 	p.mu.Lock()
-	defer p.mu.Unlock()
-	for k, table := range p.tables {
-		if k.runCnt != runCnt {
-			continue
-		}
-		if _, ok := table[addr]; !ok {
-			return
-		}
-		if table[addr] {
-			continue
-		}
-		table[addr] = true
-		elapse := time.Since(k.sentTime)
-		key, displayName := p.getTargetInfo(addr)
+	ev := p.replyEvent(runCnt, addr, recvTime, payload, packetData, packetSize)
+	p.mu.Unlock()
+	if ev != nil {
+		r <- ev
+	}
+}
 
-		// Extract detailed packet information
-		icmpDetails := p.extractICMPDetails(runCnt, addr, payload, packetData, packetSize)
-		
-		// Create ICMP detail information
-		details := &ProbeDetails{
-			ProbeType: string(p.version),
-			ICMP:      icmpDetails,
-		}
+// replyEvent updates the request's state for a reply and returns the event to
+// report, or nil. Callers must hold p.mu and send the event after unlocking.
+func (p *ICMPProber) replyEvent(runCnt int, addr string, recvTime time.Time, payload icmp.Message, packetData []byte, packetSize int) *Event {
+	state, timedOut := p.findEchoState(runCnt, addr)
+	if state == nil {
+		return nil
+	}
+	elapse := recvTime.Sub(state.sentTime)
+	if elapse <= 0 {
+		// Kernel and Go clocks disagree (e.g. clock step); fall back to now
+		elapse = time.Since(state.sentTime)
+	}
+	key, displayName := p.getTargetInfo(addr)
 
-		r <- &Event{
+	switch {
+	case state.replied:
+		return &Event{
 			Key:         key,
 			DisplayName: displayName,
-			Result:      SUCCESS,
-			SentTime:    k.sentTime,
+			Result:      DUPLICATE,
+			SentTime:    state.sentTime,
 			Rtt:         elapse,
-			Details:     details,
+			Message:     fmt.Sprintf("duplicate reply seq=%d", runCnt),
+		}
+	case timedOut:
+		state.replied = true
+		return &Event{
+			Key:         key,
+			DisplayName: displayName,
+			Result:      LATE,
+			SentTime:    state.sentTime,
+			Rtt:         elapse,
+			Message:     fmt.Sprintf("late reply seq=%d", runCnt),
+		}
+	case state.done:
+		// Already failed by an ICMP error
+		return nil
+	}
+	state.done = true
+	state.replied = true
+
+	reordered := false
+	if last, ok := p.lastSeq[addr]; ok && seqBefore(runCnt, last) {
+		reordered = true
+	} else {
+		p.lastSeq[addr] = runCnt
+	}
+
+	// Extract detailed packet information
+	icmpDetails := p.extractICMPDetails(runCnt, addr, payload, packetData, packetSize)
+
+	// Create ICMP detail information
+	details := &ProbeDetails{
+		ProbeType: string(p.version),
+		ICMP:      icmpDetails,
+	}
+
+	return &Event{
+		Key:         key,
+		DisplayName: displayName,
+		Result:      SUCCESS,
+		SentTime:    state.sentTime,
+		Rtt:         elapse,
+		Details:     details,
+		Reordered:   reordered,
+	}
+}
+
+// findEchoState returns the request sent to addr in round runCnt, searching
+// timed-out rounds too. Callers must hold p.mu.
+func (p *ICMPProber) findEchoState(runCnt int, addr string) (*echoState, bool) {
+	for k, table := range p.tables {
+		if k.runCnt == runCnt {
+			return table[addr], false
+		}
+	}
+	for k, table := range p.late {
+		if k.runCnt == runCnt {
+			return table[addr], true
 		}
-		return
 	}
+	return nil, false
+}
+
+// seqBefore reports whether sequence a was sent before b, allowing for wraparound
+func seqBefore(a, b int) bool {
+	return int16(uint16(a)-uint16(b)) < 0
 }
 
-// extractICMPDetails extracts detailed information from ICMP packet
 func (p *ICMPProber) extractICMPDetails(runCnt int, addr string, payload icmp.Message, packetData []byte, packetSize int) *ICMPDetails {
 	var payloadContent string
 	var checksum uint16
@@ -229,20 +447,7 @@ func (p *ICMPProber) extractICMPDetails(runCnt int, addr string, payload icmp.Me
 	
 	
 	// Convert ICMP type to int safely
-	var icmpType int
-	switch payload.Type {
-	case ipv4.ICMPTypeEchoReply:
-		icmpType = 0
-	case ipv6.ICMPTypeEchoReply:
-		icmpType = 129
-	default:
-		// Use reflection or type assertion for other types
-		if t, ok := payload.Type.(interface{ Int() int }); ok {
-			icmpType = t.Int()
-		} else {
-			icmpType = -1 // Unknown type
-		}
-	}
+	icmpType := icmpTypeNumber(payload.Type)
 	
 	details := &ICMPDetails{
 		Sequence:   runCnt,
@@ -287,17 +492,17 @@ func formatPayloadContent(data []byte) string {
 }
 
 func (p *ICMPProber) failed(r chan *Event, runCnt int, addr string, err error) {
+	var ev *Event
 	p.mu.Lock()
-	defer p.mu.Unlock()
 	for k, table := range p.tables {
 		if k.runCnt != runCnt {
 			continue
 		}
-		if _, ok := table[addr]; ok {
-			table[addr] = true
+		if state, ok := table[addr]; ok {
+			state.done = true
 		}
 		key, displayName := p.getTargetInfo(addr)
-		r <- &Event{
+		ev = &Event{
 			Key:         key,
 			DisplayName: displayName,
 			Result:      FAILED,
@@ -305,37 +510,53 @@ func (p *ICMPProber) failed(r chan *Event, runCnt int, addr string, err error) {
 			Rtt:         0,
 			Message:     err.Error(),
 		}
-		return
+		break
+	}
+	p.mu.Unlock()
+	if ev != nil {
+		r <- ev
 	}
 }
 
 func (p *ICMPProber) checkTimeout(r chan *Event) {
 	now := time.Now()
-	var cleanTargets []runTime
+	var (
+		cleanTargets []runTime
+		events       []*Event
+	)
 	p.mu.Lock()
-	defer p.mu.Unlock()
 	for rt, table := range p.tables {
 		if rt.sentTime.Add(p.timeout).After(now) {
 			continue
 		}
-		for t, res := range table {
-			if !res {
+		for t, state := range table {
+			if !state.done {
 				key, displayName := p.getTargetInfo(t)
-				r <- &Event{
+				events = append(events, &Event{
 					Key:         key,
 					DisplayName: displayName,
 					Result:      TIMEOUT,
 					SentTime:    rt.sentTime,
 					Rtt:         p.timeout,
 					Message:     "timeout",
-				}
+				})
 			}
 		}
 		cleanTargets = append(cleanTargets, rt)
 	}
 	for _, rt := range cleanTargets {
+		p.late[rt] = p.tables[rt]
 		delete(p.tables, rt)
 	}
+	for rt := range p.late {
+		if rt.sentTime.Add(p.timeout + lateReplyWindow).Before(now) {
+			delete(p.late, rt)
+		}
+	}
+	p.mu.Unlock()
+	for _, ev := range events {
+		r <- ev
+	}
 }
 
 func (p *ICMPProber) makeEchoMsg() icmp.Message {
@@ -356,7 +577,7 @@ func (p *ICMPProber) makeEchoMsg() icmp.Message {
 	}
 }
 
-func (p *ICMPProber) probe(r chan *Event) {
+func (p *ICMPProber) probe(r chan *Event) error {
 	p.runCnt++
 	if p.runCnt > 65535 {
 		p.runCnt = 1
@@ -365,100 +586,422 @@ func (p *ICMPProber) probe(r chan *Event) {
 
 	b, err := m.Marshal(nil)
 	if err != nil {
-		fmt.Printf("Failed ICMP encode: %s\n", err)
-		os.Exit(1)
+		return fmt.Errorf("failed ICMP encode: %w", err)
 	}
 
 	n := time.Now()
 	p.addTable(p.runCnt, n)
-	for ipStr := range p.targets {
+	for _, ipStr := range p.targetAddrs() {
 		ip, err := net.ResolveIPAddr("ip", ipStr)
 		if err != nil {
 			p.failed(r, p.runCnt, ipStr, err)
 			continue
 		}
-		_, err = p.c.WriteTo(b, ip)
+		var dst net.Addr = ip
+		if p.datagram {
+			// Datagram ICMP sockets take a UDP address
+			dst = &net.UDPAddr{IP: ip.IP, Zone: ip.Zone}
+		}
+		p.markSent(p.runCnt, ipStr)
+		_, err = p.c.WriteTo(b, dst)
 		p.sent(r, ipStr)
 		if err != nil {
 			p.failed(r, p.runCnt, ipStr, err)
 		}
 	}
+	return nil
 }
 
-func (p *ICMPProber) recvPkts(r chan *Event) {
+// recvPkts reads replies from c until a read error occurs, which is reported on errChan
+func (p *ICMPProber) recvPkts(r chan *Event, c net.PacketConn, errChan chan<- error) {
 	pktbuf := make([]byte, maxPacketSize)
+	oob := make([]byte, 128)
+	echoID := p.echoID(c)
 	for {
-		n, addr, err := p.c.ReadFrom(pktbuf)
+		n, peer, recvTime, err := readPacket(c, pktbuf, oob)
 		if err != nil {
-			fmt.Printf("Error reading ICMP packet: %s\n", err)
-			os.Exit(1)
+			select {
+			case errChan <- fmt.Errorf("error reading ICMP packet: %w", err):
+			default:
+			}
+			return
 		}
+		addr := peerIP(peer)
 		proto := ipv4.ICMPTypeEchoReply.Protocol()
 		if p.version == ICMPV6 {
 			proto = ipv6.ICMPTypeEchoReply.Protocol()
 		}
 		rm, err := icmp.ParseMessage(proto, pktbuf[:n])
 		if err != nil {
-			fmt.Printf("Error parsing ICMP message: %s\n", err)
-			os.Exit(1)
+			// Malformed packets are not ours to report; skip them
+			continue
+		}
+		switch rm.Type {
+		case ipv4.ICMPTypeDestinationUnreachable, ipv4.ICMPTypeTimeExceeded,
+			ipv6.ICMPTypeDestinationUnreachable, ipv6.ICMPTypeTimeExceeded:
+			p.handleICMPError(r, rm, addr, echoID, pktbuf[:n], n)
+			continue
 		}
 		offset := 0
 		id := binary.BigEndian.Uint16(pktbuf[offset+4 : offset+6])
-		if id != uint16(p.runID) {
+		if int(id) != echoID {
 			continue
 		}
 		seq := binary.BigEndian.Uint16(pktbuf[offset+6 : offset+8])
 		if rm.Code == 0 {
 			switch rm.Type {
 			case ipv4.ICMPTypeEchoReply, ipv6.ICMPTypeEchoReply:
-				p.success(r, int(seq), addr.String(), *rm, pktbuf[:n], n)
+				p.success(r, int(seq), addr, recvTime, *rm, pktbuf[:n], n)
 			}
 		}
 	}
 }
 
+// echoID returns the echo identifier replies on c are expected to carry.
+// On datagram sockets the kernel rewrites the identifier to the socket's local port.
+func (p *ICMPProber) echoID(c net.PacketConn) int {
+	if p.datagram {
+		if ua, ok := c.LocalAddr().(*net.UDPAddr); ok {
+			return ua.Port
+		}
+	}
+	return p.runID
+}
+
+// peerIP returns the IP address string of a reply source (net.IPAddr or net.UDPAddr)
+func peerIP(addr net.Addr) string {
+	switch a := addr.(type) {
+	case *net.UDPAddr:
+		return (&net.IPAddr{IP: a.IP, Zone: a.Zone}).String()
+	case *net.IPAddr:
+		return a.String()
+	}
+	return addr.String()
+}
+
+// handleICMPError matches a Destination Unreachable / Time Exceeded message
+// back to the echo request quoted in its body and reports it as a failure.
+func (p *ICMPProber) handleICMPError(r chan *Event, rm *icmp.Message, from string, echoID int, packetData []byte, packetSize int) {
+	var data []byte
+	switch body := rm.Body.(type) {
+	case *icmp.DstUnreach:
+		data = body.Data
+	case *icmp.TimeExceeded:
+		data = body.Data
+	default:
+		return
+	}
+
+	dst, id, seq, ok := parseEmbeddedEcho(p.version, data)
+	if !ok || id != echoID {
+		return
+	}
+
+	icmpType := icmpTypeNumber(rm.Type)
+	var checksum uint16
+	if len(packetData) >= 4 {
+		checksum = binary.BigEndian.Uint16(packetData[2:4])
+	}
+	details := &ProbeDetails{
+		ProbeType: string(p.version),
+		ICMP: &ICMPDetails{
+			Sequence:   seq,
+			PacketSize: packetSize,
+			ICMPType:   icmpType,
+			ICMPCode:   rm.Code,
+			Checksum:   checksum,
+			From:       from,
+		},
+	}
+	msg := fmt.Sprintf("%s (type=%d code=%d) from %s", icmpErrorMessage(rm.Type, rm.Code), icmpType, rm.Code, from)
+
+	var ev *Event
+	p.mu.Lock()
+	for k, table := range p.tables {
+		if k.runCnt != seq {
+			continue
+		}
+		state, ok := table[dst]
+		if !ok || state.done {
+			break
+		}
+		state.done = true
+		key, displayName := p.getTargetInfo(dst)
+		ev = &Event{
+			Key:         key,
+			DisplayName: displayName,
+			Result:      FAILED,
+			SentTime:    k.sentTime,
+			Rtt:         0,
+			Message:     msg,
+			Details:     details,
+		}
+		break
+	}
+	p.mu.Unlock()
+	if ev != nil {
+		r <- ev
+	}
+}
+
+// parseEmbeddedEcho extracts the destination address and echo ID/sequence
+// from the original datagram quoted in an ICMP error message.
+func parseEmbeddedEcho(version ProbeType, data []byte) (dst string, id, seq int, ok bool) {
+	var (
+		hdrLen   int
+		echoType byte
+	)
+	if version == ICMPV4 {
+		if len(data) < ipv4.HeaderLen || data[0]>>4 != 4 {
+			return "", 0, 0, false
+		}
+		hdrLen = int(data[0]&0x0f) * 4
+		if data[9] != 1 { // ICMP
+			return "", 0, 0, false
+		}
+		dst = net.IP(data[16:20]).String()
+		echoType = byte(ipv4.ICMPTypeEcho)
+	} else {
+		if len(data) < ipv6.HeaderLen || data[0]>>4 != 6 {
+			return "", 0, 0, false
+		}
+		hdrLen = ipv6.HeaderLen
+		if data[6] != 58 { // ICMPv6 (extension headers are not followed)
+			return "", 0, 0, false
+		}
+		dst = net.IP(data[24:40]).String()
+		echoType = byte(ipv6.ICMPTypeEchoRequest)
+	}
+
+	if hdrLen < ipv4.HeaderLen || len(data) < hdrLen+8 {
+		return "", 0, 0, false
+	}
+	echo := data[hdrLen:]
+	if echo[0] != echoType {
+		return "", 0, 0, false
+	}
+	id = int(binary.BigEndian.Uint16(echo[4:6]))
+	seq = int(binary.BigEndian.Uint16(echo[6:8]))
+	return dst, id, seq, true
+}
+
+// icmpTypeNumber returns the numeric value of an ICMP type
+func icmpTypeNumber(t icmp.Type) int {
+	switch v := t.(type) {
+	case ipv4.ICMPType:
+		return int(v)
+	case ipv6.ICMPType:
+		return int(v)
+	}
+	return -1
+}
+
+// icmpErrorMessage returns a human readable description of an ICMP error type/code
+func icmpErrorMessage(t icmp.Type, code int) string {
+	switch t {
+	case ipv4.ICMPTypeDestinationUnreachable:
+		switch code {
+		case 0:
+			return "network unreachable"
+		case 1:
+			return "host unreachable"
+		case 2:
+			return "protocol unreachable"
+		case 3:
+			return "port unreachable"
+		case 4:
+			return "fragmentation needed"
+		case 5:
+			return "source route failed"
+		case 9, 10, 13:
+			return "administratively prohibited"
+		}
+		return "destination unreachable"
+	case ipv6.ICMPTypeDestinationUnreachable:
+		switch code {
+		case 0:
+			return "no route to destination"
+		case 1:
+			return "administratively prohibited"
+		case 2:
+			return "beyond scope of source address"
+		case 3:
+			return "address unreachable"
+		case 4:
+			return "port unreachable"
+		case 5:
+			return "source address failed ingress/egress policy"
+		case 6:
+			return "reject route to destination"
+		}
+		return "destination unreachable"
+	case ipv4.ICMPTypeTimeExceeded:
+		if code == 1 {
+			return "fragment reassembly time exceeded"
+		}
+		return "ttl exceeded in transit"
+	case ipv6.ICMPTypeTimeExceeded:
+		if code == 1 {
+			return "fragment reassembly time exceeded"
+		}
+		return "hop limit exceeded in transit"
+	}
+	return "icmp error"
+}
+
 func (p *ICMPProber) emitRegistrationEvents(r chan *Event) {
-	for k, v := range p.targets {
-		r <- &Event{
-			Key:         k,
-			DisplayName: v,
+	p.mu.Lock()
+	events := make([]*Event, 0, len(p.targets))
+	for addr := range p.targets {
+		key, displayName := p.getTargetInfo(addr)
+		events = append(events, &Event{
+			Key:         key,
+			DisplayName: displayName,
 			Result:      REGISTER,
+			Group:       p.groups[addr],
+			Family:      p.families[addr],
+		})
+	}
+	p.mu.Unlock()
+	for _, ev := range events {
+		r <- ev
+	}
+}
+
+// reresolve looks up tracked hostnames again and moves targets whose address
+// changed. The metrics key stays the same so history continues on one row.
+func (p *ICMPProber) reresolve(r chan *Event) {
+	if !p.lookupMu.TryLock() {
+		return // Previous lookup still running
+	}
+	defer p.lookupMu.Unlock()
+
+	for key, n := range p.names {
+		select {
+		case <-p.exitChan:
+			return // Stopping; skip the remaining lookups
+		default:
+		}
+		old := n.current
+		msg, err := n.refresh()
+		if err != nil || msg == "" {
+			continue // Keep probing the last known address
+		}
+
+		p.mu.Lock()
+		if n.current != old {
+			if _, exists := p.targets[n.current]; exists {
+				// Another target already probes the new address
+				n.current = old
+				p.mu.Unlock()
+				continue
+			}
+			delete(p.targets, old)
+			delete(p.keys, old)
+			p.targets[n.current] = fmt.Sprintf("%s(%s)", n.host, n.current)
+			if key != n.current {
+				p.keys[n.current] = key
+			}
+			if g, ok := p.groups[old]; ok {
+				delete(p.groups, old)
+				p.groups[n.current] = g
+			}
+			if f, ok := p.families[old]; ok {
+				delete(p.families, old)
+				p.families[n.current] = f
+			}
+			// In-flight probes now belong to the new address
+			for _, tables := range []map[runTime]map[string]*echoState{p.tables, p.late} {
+				for _, table := range tables {
+					if state, ok := table[old]; ok {
+						delete(table, old)
+						table[n.current] = state
+					}
+				}
+			}
+			delete(p.lastSeq, old)
+		}
+		_, displayName := p.getTargetInfo(n.current)
+		p.mu.Unlock()
+
+		r <- &Event{
+			Key:         key,
+			DisplayName: displayName,
+			Result:      RESOLVED,
+			SentTime:    time.Now(),
+			Message:     msg,
 		}
 	}
 }
 
+// Start sends echo requests every interval until Stop is called. It returns an
+// error if the socket fails; the caller may then Reopen and Start again.
 func (p *ICMPProber) Start(r chan *Event, interval, timeout time.Duration) error {
 	p.emitRegistrationEvents(r)
 	p.timeout = timeout
 	ticker := time.NewTicker(interval)
-	go p.recvPkts(r)
+	defer ticker.Stop()
+	c := p.c
+	errChan := make(chan error, 1)
+	go p.recvPkts(r, c, errChan)
+
+	var resolveC <-chan time.Time
+	if p.config.ResolveInterval > 0 && len(p.names) > 0 {
+		resolveTicker := time.NewTicker(p.config.ResolveInterval)
+		defer resolveTicker.Stop()
+		resolveC = resolveTicker.C
+	}
+
+	var runErr error
 	p.wg.Add(1)
 	go func() {
 		defer p.wg.Done()
-		p.probe(r)
+		if runErr = p.probe(r); runErr != nil {
+			return
+		}
 		for {
 			select {
 			case <-p.exitChan:
 				return
+			case runErr = <-errChan:
+				return
 			case <-ticker.C:
-				p.probe(r)
+				if runErr = p.probe(r); runErr != nil {
+					return
+				}
 				go p.checkTimeout(r)
+			case <-resolveC:
+				// Tracked so that no RESOLVED event is sent after Start returns
+				p.wg.Add(1)
+				go func() {
+					defer p.wg.Done()
+					p.reresolve(r)
+				}()
 			}
 		}
 	}()
 	p.wg.Wait()
+	if runErr != nil {
+		return runErr
+	}
 	for {
 		p.checkTimeout(r)
-		if len(p.tables) == 0 {
+		p.mu.Lock()
+		pending := len(p.tables)
+		p.mu.Unlock()
+		if pending == 0 {
 			break
 		}
 		time.Sleep(interval)
 	}
+	c.Close()
 	return nil
 }
 
 func (p *ICMPProber) Stop() {
-	p.exitChan <- true
+	p.stopOnce.Do(func() {
+		close(p.exitChan)
+	})
 }
 
 // resolveSourceInterface resolves interface name or IP address to a bind address
@@ -1,7 +1,13 @@
 package prober
 
 import (
+	"net"
 	"testing"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
 )
 
 func TestResolveSourceInterface(t *testing.T) {
@@ -95,4 +101,288 @@ func TestResolveSourceInterfaceLoopback(t *testing.T) {
 	if addr != "127.0.0.1" {
 		t.Logf("Loopback IPv4 address: %s (expected 127.0.0.1, but this may vary)", addr)
 	}
-}
\ No newline at end of file
+}
+
+func TestParseEmbeddedEcho(t *testing.T) {
+	// IPv4 header (20 bytes) + echo request header
+	v4 := []byte{
+		0x45, 0x00, 0x00, 0x21, 0x00, 0x00, 0x40, 0x00, 0x40, 0x01, 0x00, 0x00,
+		192, 0, 2, 10, // src
+		198, 51, 100, 7, // dst
+		8, 0, 0x00, 0x00, 0x12, 0x34, 0x00, 0x2a, // echo id=0x1234 seq=42
+	}
+	dst, id, seq, ok := parseEmbeddedEcho(ICMPV4, v4)
+	if !ok {
+		t.Fatal("Expected IPv4 embedded echo to be parsed")
+	}
+	if dst != "198.51.100.7" || id != 0x1234 || seq != 42 {
+		t.Errorf("Unexpected result: dst=%s id=%d seq=%d", dst, id, seq)
+	}
+
+	// IPv6 header (40 bytes) + echo request header
+	v6 := make([]byte, 48)
+	v6[0] = 0x60
+	v6[6] = 58 // next header: ICMPv6
+	copy(v6[24:40], net.ParseIP("2001:db8::1"))
+	copy(v6[40:], []byte{128, 0, 0x00, 0x00, 0x00, 0x07, 0x00, 0x03})
+	dst, id, seq, ok = parseEmbeddedEcho(ICMPV6, v6)
+	if !ok {
+		t.Fatal("Expected IPv6 embedded echo to be parsed")
+	}
+	if dst != "2001:db8::1" || id != 7 || seq != 3 {
+		t.Errorf("Unexpected result: dst=%s id=%d seq=%d", dst, id, seq)
+	}
+
+	// Truncated, malformed and non-echo payloads are rejected
+	withByte := func(data []byte, i int, b byte) []byte {
+		data = append([]byte{}, data...)
+		data[i] = b
+		return data
+	}
+	rejected := []struct {
+		name    string
+		version ProbeType
+		data    []byte
+	}{
+		{"truncated echo", ICMPV4, v4[:24]},
+		{"echo reply", ICMPV4, withByte(v4, 20, 0)},
+		{"IHL larger than the payload", ICMPV4, withByte(v4, 0, 0x4f)},
+		{"IHL below 5", ICMPV4, withByte(v4, 0, 0x41)},
+		{"IHL zero", ICMPV4, withByte(v4, 0, 0x40)},
+		{"IPv4 header only", ICMPV4, v4[:20]},
+		{"truncated IPv6 echo", ICMPV6, v6[:44]},
+		{"IPv6 header only", ICMPV6, v6[:40]},
+	}
+	for _, tt := range rejected {
+		if _, _, _, ok := parseEmbeddedEcho(tt.version, tt.data); ok {
+			t.Errorf("%s: expected the datagram to be rejected", tt.name)
+		}
+	}
+}
+
+func TestICMPErrorMessage(t *testing.T) {
+	tests := []struct {
+		typ      icmp.Type
+		code     int
+		expected string
+	}{
+		{ipv4.ICMPTypeDestinationUnreachable, 1, "host unreachable"},
+		{ipv4.ICMPTypeDestinationUnreachable, 3, "port unreachable"},
+		{ipv4.ICMPTypeTimeExceeded, 0, "ttl exceeded in transit"},
+		{ipv6.ICMPTypeDestinationUnreachable, 0, "no route to destination"},
+		{ipv6.ICMPTypeTimeExceeded, 0, "hop limit exceeded in transit"},
+	}
+
+	for _, tt := range tests {
+		if got := icmpErrorMessage(tt.typ, tt.code); got != tt.expected {
+			t.Errorf("icmpErrorMessage(%v, %d) = %q, expected %q", tt.typ, tt.code, got, tt.expected)
+		}
+	}
+
+	if icmpTypeNumber(ipv4.ICMPTypeDestinationUnreachable) != 3 {
+		t.Errorf("Expected type number 3 for IPv4 destination unreachable")
+	}
+	if icmpTypeNumber(ipv6.ICMPTypeTimeExceeded) != 3 {
+		t.Errorf("Expected type number 3 for IPv6 time exceeded")
+	}
+}
+
+func TestPeerIP(t *testing.T) {
+	tests := []struct {
+		addr     net.Addr
+		expected string
+	}{
+		{&net.IPAddr{IP: net.ParseIP("192.0.2.1")}, "192.0.2.1"},
+		{&net.UDPAddr{IP: net.ParseIP("192.0.2.1"), Port: 0}, "192.0.2.1"},
+		{&net.UDPAddr{IP: net.ParseIP("2001:db8::1"), Port: 0}, "2001:db8::1"},
+	}
+
+	for _, tt := range tests {
+		if got := peerIP(tt.addr); got != tt.expected {
+			t.Errorf("peerIP(%v) = %s, expected %s", tt.addr, got, tt.expected)
+		}
+	}
+}
+
+func TestICMPProberUnprivileged(t *testing.T) {
+	p, err := NewICMPProber(ICMPV4, &ICMPConfig{Body: "test", Unprivileged: true}, "icmpv4")
+	if err != nil {
+		t.Skip("Unprivileged ICMP not available (see net.ipv4.ping_group_range):", err)
+	}
+	if !p.datagram {
+		t.Fatal("Expected datagram socket when unprivileged is set")
+	}
+	if err := p.Accept("icmpv4://127.0.0.1"); err != nil {
+		t.Fatalf("Failed to accept target: %v", err)
+	}
+
+	events := make(chan *Event, 100)
+	done := make(chan error, 1)
+	go func() {
+		done <- p.Start(events, 50*time.Millisecond, time.Second)
+	}()
+	defer func() {
+		p.Stop()
+		<-done
+	}()
+
+	deadline := time.After(2 * time.Second)
+	for {
+		select {
+		case e := <-events:
+			if e.Result == SUCCESS {
+				if e.Key != "127.0.0.1" {
+					t.Errorf("Expected key 127.0.0.1, got %s", e.Key)
+				}
+				return
+			}
+		case <-deadline:
+			t.Fatal("Timed out waiting for echo reply on datagram socket")
+		}
+	}
+}
+
+func TestICMPProberLoopbackRTT(t *testing.T) {
+	p, err := NewICMPProber(ICMPV4, &ICMPConfig{Body: "test"}, "icmpv4")
+	if err != nil {
+		t.Skip("ICMP socket not available:", err)
+	}
+	if err := p.Accept("icmpv4://127.0.0.1"); err != nil {
+		t.Fatalf("Failed to accept target: %v", err)
+	}
+
+	events := make(chan *Event, 100)
+	done := make(chan error, 1)
+	go func() {
+		done <- p.Start(events, 50*time.Millisecond, time.Second)
+	}()
+	defer func() {
+		p.Stop()
+		<-done
+	}()
+
+	deadline := time.After(2 * time.Second)
+	for {
+		select {
+		case e := <-events:
+			if e.Result == SUCCESS {
+				if e.Rtt <= 0 || e.Rtt > 100*time.Millisecond {
+					t.Errorf("Expected small positive loopback RTT, got %v", e.Rtt)
+				}
+				if e.Details == nil || e.Details.ICMP == nil || e.Details.ICMP.Payload == "" {
+					t.Errorf("Expected ICMP details with payload, got %+v", e.Details)
+				}
+				return
+			}
+		case <-deadline:
+			t.Fatal("Timed out waiting for echo reply")
+		}
+	}
+}
+
+func TestICMPReplyTracking(t *testing.T) {
+	sent := time.Now().Add(-time.Second)
+	p := &ICMPProber{
+		version: ICMPV4,
+		targets: map[string]string{"192.0.2.1": "192.0.2.1"},
+		tables:  make(map[runTime]map[string]*echoState),
+		late:    make(map[runTime]map[string]*echoState),
+		lastSeq: make(map[string]int),
+		timeout: 500 * time.Millisecond,
+	}
+	for _, seq := range []int{1, 2, 3} {
+		p.tables[runTime{runCnt: seq, sentTime: sent}] = map[string]*echoState{"192.0.2.1": {sentTime: sent}}
+	}
+	reply := icmp.Message{Type: ipv4.ICMPTypeEchoReply, Body: &icmp.Echo{}}
+	events := make(chan *Event, 10)
+	next := func() *Event {
+		t.Helper()
+		select {
+		case e := <-events:
+			return e
+		default:
+			t.Fatal("Expected an event")
+			return nil
+		}
+	}
+
+	p.success(events, 2, "192.0.2.1", sent.Add(10*time.Millisecond), reply, nil, 0)
+	if e := next(); e.Result != SUCCESS || e.Reordered || e.Rtt != 10*time.Millisecond {
+		t.Errorf("Expected in-order success with 10ms RTT, got %+v", e)
+	}
+	p.success(events, 2, "192.0.2.1", sent.Add(12*time.Millisecond), reply, nil, 0)
+	if e := next(); e.Result != DUPLICATE {
+		t.Errorf("Expected DUPLICATE, got %+v", e)
+	}
+	p.success(events, 1, "192.0.2.1", sent.Add(15*time.Millisecond), reply, nil, 0)
+	if e := next(); e.Result != SUCCESS || !e.Reordered {
+		t.Errorf("Expected reordered success, got %+v", e)
+	}
+
+	// Round 3 times out, then its reply arrives
+	p.checkTimeout(events)
+	if e := next(); e.Result != TIMEOUT {
+		t.Errorf("Expected TIMEOUT, got %+v", e)
+	}
+	p.success(events, 3, "192.0.2.1", sent.Add(800*time.Millisecond), reply, nil, 0)
+	if e := next(); e.Result != LATE || e.Rtt != 800*time.Millisecond {
+		t.Errorf("Expected LATE with 800ms RTT, got %+v", e)
+	}
+	p.success(events, 3, "192.0.2.1", sent.Add(900*time.Millisecond), reply, nil, 0)
+	if e := next(); e.Result != DUPLICATE {
+		t.Errorf("Expected DUPLICATE after late reply, got %+v", e)
+	}
+	if len(p.tables) != 0 {
+		t.Errorf("Expected timed-out rounds to leave the pending tables, got %d", len(p.tables))
+	}
+}
+
+func TestICMPRegistrationWithoutLock(t *testing.T) {
+	p := &ICMPProber{
+		version:  ICMPV4,
+		targets:  map[string]string{"192.0.2.1": "192.0.2.1", "192.0.2.2": "192.0.2.2", "192.0.2.3": "192.0.2.3"},
+		keys:     make(map[string]string),
+		groups:   make(map[string]string),
+		families: make(map[string]string),
+	}
+	events := make(chan *Event)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		p.emitRegistrationEvents(events)
+	}()
+
+	// After the first event the sender waits for the next read; the prober
+	// must stay unlocked meanwhile
+	<-events
+	if !p.mu.TryLock() {
+		t.Error("Expected the prober to be unlocked while sending registration events")
+	} else {
+		p.mu.Unlock()
+	}
+	registered := 1
+	for registered < len(p.targets) {
+		if e := <-events; e.Result == REGISTER {
+			registered++
+		}
+	}
+	<-done
+}
+
+func TestSeqBefore(t *testing.T) {
+	tests := []struct {
+		a, b     int
+		expected bool
+	}{
+		{1, 2, true},
+		{2, 1, false},
+		{65535, 1, true}, // Wraparound
+		{1, 65535, false},
+		{5, 5, false},
+	}
+	for _, tt := range tests {
+		if got := seqBefore(tt.a, tt.b); got != tt.expected {
+			t.Errorf("seqBefore(%d, %d) = %v, expected %v", tt.a, tt.b, got, tt.expected)
+		}
+	}
+}
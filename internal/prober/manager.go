@@ -8,11 +8,17 @@ import (
 	"time"
 )
 
+// maxQueuedEvents bounds the events queued for slow subscribers, about 50
+// seconds of events for a thousand targets probed every second
+const maxQueuedEvents = 100000
+
 // ProbeManager manages the lifecycle of probing operations
 type ProbeManager interface {
 	AddTargets(targets ...string) error
 	Run(ctx context.Context, interval, timeout time.Duration) error
 	Events() <-chan *Event
+	Subscribe(name string, buffer int, policy DeliveryPolicy) *Subscription
+	QueueStatus
 	Stop()
 }
 
@@ -22,6 +28,9 @@ type probeManager struct {
 	defaultType string
 	probers     map[string]Prober
 	eventChan   chan *Event
+	broadcast   *Broadcaster
+	events      *Subscription // Created by the first call to Events
+	eventsOnce  sync.Once
 	wg          sync.WaitGroup
 	mu          sync.Mutex
 	running     bool
@@ -30,10 +39,12 @@ type probeManager struct {
 
 // NewProbeManager creates a new ProbeManager instance
 func NewProbeManager(proberConfigs map[string]*ProberConfig, defaultType string) ProbeManager {
+	eventChan := make(chan *Event, 1000) // Buffered channel for events
 	return &probeManager{
 		config:      proberConfigs,
 		defaultType: defaultType,
-		eventChan:   make(chan *Event, 1000), // Buffered channel for events
+		eventChan:   eventChan,
+		broadcast:   NewBroadcaster(eventChan, maxQueuedEvents),
 		probers:     make(map[string]Prober),
 	}
 }
@@ -76,14 +87,15 @@ func (pm *probeManager) Run(ctx context.Context, interval, timeout time.Duration
 	runCtx, cancel := context.WithCancel(ctx)
 	pm.cancel = cancel
 	pm.mu.Unlock()
+	pm.broadcast.Start()
 
 	// Start all probers
-	for _, prober := range pm.probers {
+	for name, prober := range pm.probers {
 		pm.wg.Add(1)
-		go func(p Prober) {
+		go func(name string, p Prober) {
 			defer pm.wg.Done()
-			p.Start(pm.eventChan, interval, timeout)
-		}(prober)
+			pm.runProber(runCtx, name, p, interval, timeout)
+		}(name, prober)
 	}
 
 	// Wait for context cancellation
@@ -93,9 +105,76 @@ func (pm *probeManager) Run(ctx context.Context, interval, timeout time.Duration
 	return nil
 }
 
-// Events returns the event channel for receiving probe results
+// runProber runs a single prober, reporting errors returned by Start as ERROR
+// events and reopening the prober until it recovers or the context is cancelled
+func (pm *probeManager) runProber(ctx context.Context, name string, p Prober, interval, timeout time.Duration) {
+	for {
+		err := p.Start(pm.eventChan, interval, timeout)
+		if err == nil || ctx.Err() != nil {
+			return
+		}
+		pm.eventChan <- &Event{
+			Key:         name,
+			DisplayName: name,
+			Result:      ERROR,
+			SentTime:    time.Now(),
+			Message:     err.Error(),
+		}
+
+		r, ok := p.(Reopener)
+		if !ok {
+			return
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(interval):
+			}
+			if err := r.Reopen(); err != nil {
+				pm.eventChan <- &Event{
+					Key:         name,
+					DisplayName: name,
+					Result:      ERROR,
+					SentTime:    time.Now(),
+					Message:     fmt.Sprintf("reopen failed: %v", err),
+				}
+				continue
+			}
+			break
+		}
+		pm.eventChan <- &Event{
+			Key:         name,
+			DisplayName: name,
+			Result:      RECOVERED,
+			SentTime:    time.Now(),
+		}
+	}
+}
+
+// Events returns a subscription to every event that waits for its reader when
+// the buffer is full. Every call returns the same channel; use Subscribe for
+// further consumers.
 func (pm *probeManager) Events() <-chan *Event {
-	return pm.eventChan
+	pm.eventsOnce.Do(func() {
+		pm.events = pm.Subscribe("events", cap(pm.eventChan), Block)
+	})
+	return pm.events.Events()
+}
+
+// Subscribe adds a consumer of the probe events; see Broadcaster.Subscribe
+func (pm *probeManager) Subscribe(name string, buffer int, policy DeliveryPolicy) *Subscription {
+	return pm.broadcast.Subscribe(name, buffer, policy)
+}
+
+// QueueDepth returns the number of events not yet delivered to every subscriber
+func (pm *probeManager) QueueDepth() int {
+	return pm.broadcast.QueueDepth()
+}
+
+// Dropped returns the number of events dropped because delivery fell behind
+func (pm *probeManager) Dropped() uint64 {
+	return pm.broadcast.Dropped()
 }
 
 // Stop stops all probing operations
@@ -134,6 +213,11 @@ func (pm *probeManager) routeTarget(target string) error {
 		return fmt.Errorf("unable to determine prober type for target: %s", target)
 	}
 
+	// dual@host on an ICMP prober is probed by both the icmpv4 and icmpv6 probers
+	if pm.isDualStackICMP(transformedTarget, proberType) {
+		return pm.routeDualStackICMP(transformedTarget, proberType)
+	}
+
 	// Get or create prober for this type
 	prober, err := pm.getOrCreateProber(proberType)
 	if err != nil {
@@ -149,6 +233,39 @@ func (pm *probeManager) routeTarget(target string) error {
 	return nil
 }
 
+// isDualStackICMP reports whether target is a dual@ target for an ICMP prober
+func (pm *probeManager) isDualStackICMP(target, proberType string) bool {
+	cfg := pm.config[proberType]
+	if cfg == nil || (cfg.Probe != ICMPV4 && cfg.Probe != ICMPV6) {
+		return false
+	}
+	_, host, _ := strings.Cut(target, "://")
+	return strings.HasPrefix(host, dualStackPrefix)
+}
+
+// routeDualStackICMP routes a dual@ target to the given prober and to the prober
+// of the other ICMP version, which is looked up by its probe type name
+func (pm *probeManager) routeDualStackICMP(target, proberType string) error {
+	_, host, _ := strings.Cut(target, "://")
+	names := map[ProbeType]string{ICMPV4: string(ICMPV4), ICMPV6: string(ICMPV6)}
+	names[pm.config[proberType].Probe] = proberType
+
+	for _, t := range []ProbeType{ICMPV4, ICMPV6} {
+		name := names[t]
+		if cfg, exists := pm.config[name]; !exists || cfg.Probe != t {
+			return fmt.Errorf("dual-stack target %s requires a %s prober", target, t)
+		}
+		prober, err := pm.getOrCreateProber(name)
+		if err != nil {
+			return fmt.Errorf("failed to get prober for %s: %w", name, err)
+		}
+		if err := prober.Accept(name + "://" + host); err != nil {
+			return fmt.Errorf("prober %s rejected target %s: %w", name, host, err)
+		}
+	}
+	return nil
+}
+
 // transformTarget transforms target with appropriate prefix and returns (transformedTarget, proberType)
 func (pm *probeManager) transformTarget(target string) (string, string) {
 	// Check for name://target format
@@ -25,6 +25,10 @@ func TestProbeManagerDetailedScenarios(t *testing.T) {
 		if events == nil {
 			t.Error("Events channel should not be nil")
 		}
+		// Further calls share the subscription instead of adding one that nobody reads
+		if pm.Events() != events || len(pm.(*probeManager).broadcast.subs) != 1 {
+			t.Error("Expected Events to return the same subscription")
+		}
 	})
 
 	t.Run("AddTargets with running manager", func(t *testing.T) {
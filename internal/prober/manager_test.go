@@ -1,8 +1,12 @@
 package prober
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestTransformTarget(t *testing.T) {
@@ -270,3 +274,172 @@ func TestHTTPProberTLSConfig(t *testing.T) {
 		})
 	}
 }
+
+// flakyProber fails its first Start call and runs normally after Reopen
+type flakyProber struct {
+	starts   int
+	reopened int
+	stop     chan struct{}
+}
+
+func (p *flakyProber) Accept(target string) error { return nil }
+
+func (p *flakyProber) Start(r chan *Event, interval, timeout time.Duration) error {
+	p.starts++
+	if p.starts == 1 {
+		return errors.New("socket closed")
+	}
+	<-p.stop
+	return nil
+}
+
+func (p *flakyProber) Stop() { close(p.stop) }
+
+func (p *flakyProber) Reopen() error {
+	p.reopened++
+	return nil
+}
+
+func TestRunProberReopensAfterError(t *testing.T) {
+	fp := &flakyProber{stop: make(chan struct{})}
+	pm := &probeManager{
+		eventChan: make(chan *Event, 10),
+		probers:   map[string]Prober{"fake": fp},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		pm.runProber(ctx, "fake", fp, 10*time.Millisecond, 10*time.Millisecond)
+		close(done)
+	}()
+
+	for _, expected := range []reason{ERROR, RECOVERED} {
+		select {
+		case e := <-pm.eventChan:
+			if e.Result != expected || e.Key != "fake" {
+				t.Fatalf("Expected event %v for prober 'fake', got %v for %s", expected, e.Result, e.Key)
+			}
+			if expected == ERROR && e.Message != "socket closed" {
+				t.Errorf("Expected error message 'socket closed', got %q", e.Message)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out waiting for %v event", expected)
+		}
+	}
+
+	cancel()
+	fp.Stop()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("runProber did not return after stop")
+	}
+	if fp.reopened != 1 || fp.starts != 2 {
+		t.Errorf("Expected 1 reopen and 2 starts, got %d reopens and %d starts", fp.reopened, fp.starts)
+	}
+}
+
+func TestIsDualStackICMP(t *testing.T) {
+	pm := &probeManager{
+		config: map[string]*ProberConfig{
+			"icmpv4":  {Probe: ICMPV4, ICMP: &ICMPConfig{}},
+			"icmpv6":  {Probe: ICMPV6, ICMP: &ICMPConfig{}},
+			"my-ping": {Probe: ICMPV4, ICMP: &ICMPConfig{}},
+			"tcp":     {Probe: TCP, TCP: &TCPConfig{}},
+		},
+	}
+
+	tests := []struct {
+		target, proberType string
+		expected           bool
+	}{
+		{"icmpv4://dual@example.com", "icmpv4", true},
+		{"my-ping://dual@example.com", "my-ping", true},
+		{"icmpv4://example.com", "icmpv4", false},
+		{"tcp://dual@example.com:443", "tcp", false}, // TCP handles dual@ itself
+	}
+	for _, tt := range tests {
+		if got := pm.isDualStackICMP(tt.target, tt.proberType); got != tt.expected {
+			t.Errorf("isDualStackICMP(%s, %s) = %v, expected %v", tt.target, tt.proberType, got, tt.expected)
+		}
+	}
+}
+
+// floodProber sends a probe and a result for each of its targets, closes done
+// once every send has completed and then waits to be stopped
+type floodProber struct {
+	targets int
+	rounds  int
+	stop    chan struct{}
+	done    chan struct{}
+	sent    int
+}
+
+func (p *floodProber) Accept(target string) error { return nil }
+
+func (p *floodProber) Start(r chan *Event, interval, timeout time.Duration) error {
+	for i := 0; i < p.targets; i++ {
+		r <- &Event{Key: fmt.Sprintf("192.0.2.%d", i), Result: REGISTER}
+	}
+	p.sent = p.targets
+	for round := 0; round < p.rounds; round++ {
+		sentTime := time.Now()
+		for i := 0; i < p.targets; i++ {
+			key := fmt.Sprintf("192.0.2.%d", i)
+			r <- &Event{Key: key, Result: SENT, SentTime: sentTime}
+			r <- &Event{Key: key, Result: SUCCESS, SentTime: sentTime, Rtt: time.Millisecond}
+		}
+		p.sent += 2 * p.targets
+	}
+	close(p.done)
+	<-p.stop
+	return nil
+}
+
+func (p *floodProber) Stop() { close(p.stop) }
+
+func TestProbeManagerStalledSubscriber(t *testing.T) {
+	fp := &floodProber{targets: 10000, rounds: 1, stop: make(chan struct{}), done: make(chan struct{})}
+	pm := NewProbeManager(nil, "").(*probeManager)
+	pm.probers["flood"] = fp
+	pm.broadcast = NewBroadcaster(pm.eventChan, 5000)
+	stalled := pm.Subscribe("stalled", 0, Block)
+
+	// Far more events than the channels and the queue hold, with the
+	// registration alone overflowing them; a send that waited for the
+	// subscriber would never complete
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	blocked := false
+	go func() {
+		select {
+		case <-fp.done:
+		case <-time.After(10 * time.Second):
+			blocked = true
+		}
+		cancel()
+	}()
+	if err := pm.Run(ctx, time.Second, time.Second); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if blocked {
+		t.Fatalf("Expected every send to complete while the subscriber was stalled, %d events were sent", fp.sent)
+	}
+	if pm.QueueDepth() == 0 || pm.Dropped() == 0 {
+		t.Errorf("Expected queued and dropped events, got depth %d and %d dropped", pm.QueueDepth(), pm.Dropped())
+	}
+
+	// Once the subscriber catches up it receives everything that was not dropped
+	var delivered int
+	for range stalled.Events() {
+		delivered++
+	}
+	if delivered+int(pm.Dropped()) != fp.sent {
+		t.Errorf("Expected %d events delivered or dropped, got %d delivered and %d dropped", fp.sent, delivered, pm.Dropped())
+	}
+	if pm.QueueDepth() != 0 {
+		t.Errorf("Expected an empty queue, got %d", pm.QueueDepth())
+	}
+}
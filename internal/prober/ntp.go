@@ -27,6 +27,9 @@ type (
 		Server    string        `yaml:"server"`
 		Port      int           `yaml:"port,omitempty"`
 		MaxOffset time.Duration `yaml:"max_offset,omitempty"` // Alert if offset > this value
+
+		SourceInterface string `yaml:"source_interface,omitempty"` // Interface name or IP address to send from
+		BindToDevice    string `yaml:"bind_to_device,omitempty"`   // Send through this interface or VRF (Linux SO_BINDTODEVICE)
 	}
 
 	// NTP packet structure (simplified)
@@ -190,7 +193,12 @@ func (p *NTPProber) sendProbe(result chan *Event, serverAddr string, timeout tim
 	}
 
 	// Connect to NTP server
-	conn, err := net.DialTimeout("udp", net.JoinHostPort(host, portStr), timeout)
+	dialer, err := newDialer("udp", p.config.SourceInterface, p.config.BindToDevice, timeout)
+	if err != nil {
+		p.failed(result, serverAddr, displayName, now, err)
+		return
+	}
+	conn, err := dialer.Dial("udp", net.JoinHostPort(host, portStr))
 	if err != nil {
 		p.failed(result, serverAddr, displayName, now, err)
 		return
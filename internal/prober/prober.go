@@ -2,6 +2,7 @@ package prober
 
 import (
 	"errors"
+	"fmt"
 	"time"
 )
 
@@ -16,21 +17,70 @@ const (
 	SUCCESS
 	TIMEOUT
 	FAILED
+	ERROR     // Prober-level error (e.g. socket failure); Key is the prober name
+	RECOVERED // Prober recovered from a previous ERROR
+	RESOLVED  // Target hostname re-resolved to a different address; Message describes the change
+	DUPLICATE // Another reply to a probe that was already answered; Rtt is measured from the probe
+	LATE      // Reply to a probe that already timed out; Rtt is measured from the probe
+	NOTICE    // Prober-level information that is not an error (e.g. a fallback); Key is the prober name
 
 	maxPacketSize = 1500
 )
 
+var reasonNames = [...]string{
+	REGISTER:  "REGISTER",
+	SENT:      "SENT",
+	SUCCESS:   "SUCCESS",
+	TIMEOUT:   "TIMEOUT",
+	FAILED:    "FAILED",
+	ERROR:     "ERROR",
+	RECOVERED: "RECOVERED",
+	RESOLVED:  "RESOLVED",
+	DUPLICATE: "DUPLICATE",
+	LATE:      "LATE",
+	NOTICE:    "NOTICE",
+}
+
+func (r reason) String() string {
+	if int(r) < len(reasonNames) {
+		return reasonNames[r]
+	}
+	return fmt.Sprintf("reason(%d)", r)
+}
+
+// MarshalText encodes the reason by name, so that recorded events do not
+// depend on the order of the constants
+func (r reason) MarshalText() ([]byte, error) {
+	if int(r) >= len(reasonNames) {
+		return nil, fmt.Errorf("unknown event result %d", r)
+	}
+	return []byte(reasonNames[r]), nil
+}
+
+func (r *reason) UnmarshalText(b []byte) error {
+	for i, name := range reasonNames {
+		if name == string(b) {
+			*r = reason(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown event result %q", b)
+}
+
 // Common errors
 var ErrNotAccepted = errors.New("target not accepted by this prober")
 
 type Event struct {
-	Key         string
-	DisplayName string
-	Result      reason
-	SentTime    time.Time
-	Rtt         time.Duration
-	Message     string
-	Details     *ProbeDetails // Added: detailed information
+	Key         string        `json:"key"`
+	DisplayName string        `json:"display_name,omitempty"`
+	Result      reason        `json:"result"`
+	SentTime    time.Time     `json:"sent_time"`
+	Rtt         time.Duration `json:"rtt,omitempty"`
+	Message     string        `json:"message,omitempty"`
+	Details     *ProbeDetails `json:"details,omitempty"`   // Added: detailed information
+	Group       string        `json:"group,omitempty"`     // Hostname shared by targets expanded from one name (REGISTER only)
+	Family      string        `json:"family,omitempty"`    // "v4" or "v6" for dual-stack comparison targets (REGISTER only)
+	Reordered   bool          `json:"reordered,omitempty"` // Reply arrived after the reply to a later probe (SUCCESS only)
 }
 
 type Prober interface {
@@ -38,3 +88,9 @@ type Prober interface {
 	Start(chan *Event, time.Duration, time.Duration) error
 	Stop()
 }
+
+// Reopener is implemented by probers that can recover from a failed Start
+// by reopening their underlying socket
+type Reopener interface {
+	Reopen() error
+}
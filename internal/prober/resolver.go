@@ -0,0 +1,109 @@
+package prober
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+)
+
+// lookupIP resolves host for the given network ("ip", "ip4" or "ip6").
+// It is a variable so tests can replace the resolver.
+var lookupIP = func(network, host string) ([]net.IP, error) {
+	return net.DefaultResolver.LookupIP(context.Background(), network, host)
+}
+
+// resolvedName tracks the addresses a target hostname resolves to so that
+// probers can follow address changes (e.g. a load balancer moving to a new IP)
+type resolvedName struct {
+	host    string
+	network string   // "ip", "ip4" or "ip6"
+	all     bool     // Report changes to the whole A/AAAA record set
+	current string   // Address currently being probed
+	addrs   []string // Sorted record set from the last lookup
+}
+
+func newResolvedName(host, network, current string, all bool) *resolvedName {
+	return &resolvedName{
+		host:    host,
+		network: network,
+		all:     all,
+		current: current,
+		addrs:   []string{current},
+	}
+}
+
+// refresh re-resolves the hostname and returns a description of the change,
+// or an empty string if the probed address (and, with all, the record set)
+// is unchanged. The probed address only moves when it disappears from DNS,
+// so round-robin ordering does not cause spurious changes.
+func (n *resolvedName) refresh() (string, error) {
+	ips, err := lookupIP(n.network, n.host)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve '%s': %w", n.host, err)
+	}
+	if len(ips) == 0 {
+		return "", fmt.Errorf("no addresses found for '%s'", n.host)
+	}
+
+	addrs := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		addrs = append(addrs, ip.String())
+	}
+	first := addrs[0]
+	slices.Sort(addrs)
+	addrs = slices.Compact(addrs)
+
+	prev := n.addrs
+	n.addrs = addrs
+	if !slices.Contains(addrs, n.current) {
+		old := n.current
+		n.current = first
+		return fmt.Sprintf("%s resolved to %s (was %s)", n.host, n.current, old), nil
+	}
+	if n.all && !slices.Equal(prev, addrs) {
+		return fmt.Sprintf("%s records changed: [%s] -> [%s]", n.host, strings.Join(prev, " "), strings.Join(addrs, " ")), nil
+	}
+	return "", nil
+}
+
+// allAddressesPrefix marks a hostname to be expanded into one target per
+// resolved address (e.g. icmpv4://all@example.com)
+const allAddressesPrefix = "all@"
+
+// cutAllAddresses strips the all@ marker from host and reports whether it was present
+func cutAllAddresses(host string) (string, bool) {
+	return strings.CutPrefix(host, allAddressesPrefix)
+}
+
+// dualStackPrefix marks a hostname to be probed over both IPv4 and IPv6 for
+// side by side comparison (e.g. dual@example.com, tcp://dual@example.com:443)
+const dualStackPrefix = "dual@"
+
+// cutDualStack strips the dual@ marker from host and reports whether it was present
+func cutDualStack(host string) (string, bool) {
+	return strings.CutPrefix(host, dualStackPrefix)
+}
+
+// lookupAll returns every address host resolves to, sorted and de-duplicated
+func lookupAll(network, host string) ([]string, error) {
+	ips, err := lookupIP(network, host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve '%s': %w", host, err)
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("no addresses found for '%s'", host)
+	}
+	addrs := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		addrs = append(addrs, ip.String())
+	}
+	slices.Sort(addrs)
+	return slices.Compact(addrs), nil
+}
+
+// isIPLiteral reports whether host is an IP address rather than a name
+func isIPLiteral(host string) bool {
+	return net.ParseIP(host) != nil
+}
@@ -0,0 +1,214 @@
+package prober
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stubLookup replaces lookupIP for the duration of the test
+func stubLookup(t *testing.T, addrs *[]string) {
+	orig := lookupIP
+	t.Cleanup(func() { lookupIP = orig })
+	lookupIP = func(network, host string) ([]net.IP, error) {
+		if len(*addrs) == 0 {
+			return nil, errors.New("no such host")
+		}
+		var ips []net.IP
+		for _, a := range *addrs {
+			ips = append(ips, net.ParseIP(a))
+		}
+		return ips, nil
+	}
+}
+
+func TestResolvedNameRefresh(t *testing.T) {
+	addrs := []string{"192.0.2.1"}
+	stubLookup(t, &addrs)
+
+	n := newResolvedName("example.com", "ip4", "192.0.2.1", false)
+	if msg, err := n.refresh(); err != nil || msg != "" {
+		t.Fatalf("Expected no change, got msg=%q err=%v", msg, err)
+	}
+
+	// Round-robin reordering keeps the current address
+	addrs = []string{"192.0.2.2", "192.0.2.1"}
+	if msg, _ := n.refresh(); msg != "" || n.current != "192.0.2.1" {
+		t.Errorf("Expected current address to be kept, got msg=%q current=%s", msg, n.current)
+	}
+
+	// Current address removed moves to the first returned address
+	addrs = []string{"192.0.2.3", "192.0.2.2"}
+	msg, err := n.refresh()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if n.current != "192.0.2.3" || !strings.Contains(msg, "192.0.2.3 (was 192.0.2.1)") {
+		t.Errorf("Expected move to 192.0.2.3, got msg=%q current=%s", msg, n.current)
+	}
+
+	// Lookup failures keep the last known address
+	addrs = nil
+	if _, err := n.refresh(); err == nil || n.current != "192.0.2.3" {
+		t.Errorf("Expected error and unchanged address, got err=%v current=%s", err, n.current)
+	}
+}
+
+func TestResolvedNameRefreshAll(t *testing.T) {
+	addrs := []string{"192.0.2.1", "192.0.2.2"}
+	stubLookup(t, &addrs)
+
+	n := newResolvedName("example.com", "ip4", "192.0.2.1", true)
+	n.refresh() // baseline
+	if msg, _ := n.refresh(); msg != "" {
+		t.Errorf("Expected no change, got %q", msg)
+	}
+
+	addrs = []string{"192.0.2.1", "192.0.2.4"}
+	msg, _ := n.refresh()
+	if msg != "example.com records changed: [192.0.2.1 192.0.2.2] -> [192.0.2.1 192.0.2.4]" {
+		t.Errorf("Unexpected message: %q", msg)
+	}
+	if n.current != "192.0.2.1" {
+		t.Errorf("Expected probed address to stay 192.0.2.1, got %s", n.current)
+	}
+}
+
+func TestICMPProberReresolve(t *testing.T) {
+	addrs := []string{"192.0.2.10"}
+	stubLookup(t, &addrs)
+
+	p := &ICMPProber{
+		version: ICMPV4,
+		prefix:  "icmpv4",
+		config:  &ICMPConfig{ResolveInterval: 1},
+		targets: map[string]string{"192.0.2.1": "example.com(192.0.2.1)"},
+		keys:    make(map[string]string),
+		names:   map[string]*resolvedName{"192.0.2.1": newResolvedName("example.com", "ip4", "192.0.2.1", false)},
+		tables:  map[runTime]map[string]*echoState{{runCnt: 1}: {"192.0.2.1": {}}},
+	}
+
+	events := make(chan *Event, 10)
+	p.reresolve(events)
+
+	select {
+	case e := <-events:
+		if e.Result != RESOLVED || e.Key != "192.0.2.1" || e.DisplayName != "example.com(192.0.2.10)" {
+			t.Errorf("Unexpected event: %+v", e)
+		}
+	default:
+		t.Fatal("Expected RESOLVED event")
+	}
+
+	// Results for the new address are reported under the original key
+	key, displayName := p.getTargetInfo("192.0.2.10")
+	if key != "192.0.2.1" || displayName != "example.com(192.0.2.10)" {
+		t.Errorf("Unexpected target info: key=%s displayName=%s", key, displayName)
+	}
+	if _, ok := p.targets["192.0.2.1"]; ok {
+		t.Error("Expected old address to be removed from targets")
+	}
+	if _, ok := p.tables[runTime{runCnt: 1}]["192.0.2.10"]; !ok {
+		t.Error("Expected in-flight probe to move to the new address")
+	}
+}
+
+func TestAcceptAllAddresses(t *testing.T) {
+	addrs := []string{"192.0.2.2", "192.0.2.1", "192.0.2.2"}
+	stubLookup(t, &addrs)
+
+	p := &ICMPProber{
+		version: ICMPV4,
+		prefix:  "icmpv4",
+		config:  &ICMPConfig{},
+		targets: make(map[string]string),
+		keys:    make(map[string]string),
+		names:   make(map[string]*resolvedName),
+		groups:  make(map[string]string),
+	}
+	if err := p.Accept("icmpv4://all@example.com"); err != nil {
+		t.Fatalf("Failed to accept target: %v", err)
+	}
+	if len(p.targets) != 2 || p.targets["192.0.2.1"] != "example.com(192.0.2.1)" || p.groups["192.0.2.2"] != "example.com" {
+		t.Errorf("Unexpected ICMP targets: %v groups: %v", p.targets, p.groups)
+	}
+
+	tp := NewTCPProber(&TCPConfig{}, "tcp")
+	if err := tp.Accept("tcp://all@example.com:443"); err != nil {
+		t.Fatalf("Failed to accept target: %v", err)
+	}
+	if len(tp.targets) != 2 || tp.groups["192.0.2.1:443"] != "tcp://example.com:443" {
+		t.Errorf("Unexpected TCP targets: %v groups: %v", tp.targets, tp.groups)
+	}
+}
+
+func TestTCPAcceptDualStack(t *testing.T) {
+	orig := lookupIP
+	t.Cleanup(func() { lookupIP = orig })
+	lookupIP = func(network, host string) ([]net.IP, error) {
+		if network == "ip6" {
+			return []net.IP{net.ParseIP("2001:db8::1")}, nil
+		}
+		return []net.IP{net.ParseIP("192.0.2.1")}, nil
+	}
+
+	p := NewTCPProber(&TCPConfig{}, "tcp")
+	if err := p.Accept("tcp://dual@example.com:443"); err != nil {
+		t.Fatalf("Failed to accept target: %v", err)
+	}
+	if p.families["192.0.2.1:443"] != "v4" || p.families["[2001:db8::1]:443"] != "v6" {
+		t.Errorf("Unexpected families: %v", p.families)
+	}
+	if p.groups["[2001:db8::1]:443"] != "tcp://dual@example.com:443" {
+		t.Errorf("Unexpected groups: %v", p.groups)
+	}
+}
+
+func TestTCPProberStopWaitsForReresolve(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	orig := lookupIP
+	t.Cleanup(func() { lookupIP = orig })
+	lookupIP = func(network, host string) ([]net.IP, error) {
+		close(started)
+		<-release
+		return []net.IP{net.ParseIP("192.0.2.10")}, nil
+	}
+
+	p := NewTCPProber(&TCPConfig{ResolveInterval: time.Millisecond}, "tcp")
+	p.names["192.0.2.1:80"] = newResolvedName("example.com", "ip", "192.0.2.1", false)
+	events := make(chan *Event, 10)
+	done := make(chan struct{})
+	go func() {
+		p.Start(events, time.Hour, time.Second)
+		close(done)
+	}()
+
+	<-started
+	stopped := make(chan struct{})
+	go func() {
+		p.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		t.Fatal("Expected Stop to wait for the running lookup")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	<-stopped
+	<-done
+
+	// The event was sent before Stop returned, so closing the channel is safe
+	close(events)
+	var resolved int
+	for e := range events {
+		if e.Result == RESOLVED {
+			resolved++
+		}
+	}
+	if resolved != 1 {
+		t.Errorf("Expected 1 RESOLVED event, got %d", resolved)
+	}
+}
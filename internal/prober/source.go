@@ -0,0 +1,54 @@
+package prober
+
+import (
+	"fmt"
+	"net"
+	"time"
+)
+
+// resolveSourceIP resolves an interface name or IP address to a local IP for
+// binding outgoing connections. Interface names prefer an IPv4 address and fall
+// back to IPv6; the dialer then only uses destination addresses of that family.
+func resolveSourceIP(sourceInterface string) (net.IP, error) {
+	if ip := net.ParseIP(sourceInterface); ip != nil {
+		return ip, nil
+	}
+	addr, err := resolveSourceInterface(sourceInterface, ICMPV4)
+	if err != nil {
+		addr6, err6 := resolveSourceInterface(sourceInterface, ICMPV6)
+		if err6 != nil {
+			return nil, err
+		}
+		addr = addr6
+	}
+	return net.ParseIP(addr), nil
+}
+
+// sourceLocalAddr returns the local address to bind for network ("tcp*" or "udp*"),
+// or nil if sourceInterface is empty
+func sourceLocalAddr(sourceInterface, network string) (net.Addr, error) {
+	if sourceInterface == "" {
+		return nil, nil
+	}
+	ip, err := resolveSourceIP(sourceInterface)
+	if err != nil {
+		return nil, err
+	}
+	if len(network) >= 3 && network[:3] == "udp" {
+		return &net.UDPAddr{IP: ip}, nil
+	}
+	return &net.TCPAddr{IP: ip}, nil
+}
+
+// newDialer returns a dialer for network bound to sourceInterface and device, if set
+func newDialer(network, sourceInterface, device string, timeout time.Duration) (*net.Dialer, error) {
+	localAddr, err := sourceLocalAddr(sourceInterface, network)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve source interface: %w", err)
+	}
+	d := &net.Dialer{Timeout: timeout, LocalAddr: localAddr}
+	if device != "" {
+		d.Control = bindToDevice(device)
+	}
+	return d, nil
+}
@@ -0,0 +1,58 @@
+package prober
+
+import (
+	"net"
+	"testing"
+)
+
+func TestSourceLocalAddr(t *testing.T) {
+	if addr, err := sourceLocalAddr("", "tcp"); err != nil || addr != nil {
+		t.Errorf("empty source: got %v, %v; want nil, nil", addr, err)
+	}
+
+	addr, err := sourceLocalAddr("127.0.0.1", "tcp")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if a, ok := addr.(*net.TCPAddr); !ok || !a.IP.Equal(net.ParseIP("127.0.0.1")) {
+		t.Errorf("tcp: got %#v, want TCPAddr 127.0.0.1", addr)
+	}
+
+	addr, err = sourceLocalAddr("::1", "udp6")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if a, ok := addr.(*net.UDPAddr); !ok || !a.IP.Equal(net.IPv6loopback) {
+		t.Errorf("udp6: got %#v, want UDPAddr ::1", addr)
+	}
+
+	if _, err := sourceLocalAddr("nonexistent-if0", "tcp"); err == nil {
+		t.Error("expected error for unknown interface")
+	}
+}
+
+func TestHTTPSourceDial(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		if c, err := ln.Accept(); err == nil {
+			c.Close()
+		}
+	}()
+
+	conn, err := sourceDialContext("127.0.0.1", "")(t.Context(), "tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+	if ip := conn.LocalAddr().(*net.TCPAddr).IP; !ip.Equal(net.ParseIP("127.0.0.1")) {
+		t.Errorf("local address = %v, want 127.0.0.1", ip)
+	}
+
+	if _, err := sourceDialContext("nonexistent-if0", "")(t.Context(), "tcp", ln.Addr().String()); err == nil {
+		t.Error("expected error for unknown interface")
+	}
+}
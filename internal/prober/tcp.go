@@ -1,40 +1,87 @@
 package prober
 
 import (
+	"errors"
 	"fmt"
 	"net"
+	"regexp"
+	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 )
 
 const (
 	TCP ProbeType = "tcp"
+
+	defaultBannerBytes = 256
 )
 
 type (
 	TCPProber struct {
-		targets  map[string]string // key (ip:port) -> displayName (host:port)
+		targets  map[string]string        // key (ip:port) -> displayName (host:port)
+		addrs    map[string]string        // key -> ip:port currently probed, for re-resolved targets
+		names    map[string]*resolvedName // key -> hostname tracked for re-resolution
+		groups   map[string]string        // key -> host:port, for names expanded to all addresses
+		families map[string]string        // key -> "v4"/"v6", for dual-stack comparison targets
 		config   *TCPConfig
 		prefix   string
 		exitChan chan bool
 		wg       sync.WaitGroup
+		mu       sync.Mutex // Protects addrs
+		lookupMu sync.Mutex
+		banner   *regexp.Regexp
+		syn      *synProber // Raw socket prober when SYN mode is enabled and permitted
 	}
 
 	TCPConfig struct {
 		SourceInterface string `yaml:"source_interface,omitempty"`
+		BindToDevice    string `yaml:"bind_to_device,omitempty"` // Send through this interface or VRF (Linux SO_BINDTODEVICE)
+
+		ResolveInterval time.Duration `yaml:"resolve_interval,omitempty"` // Re-resolve hostnames periodically (0 disables)
+		ResolveAll      bool          `yaml:"resolve_all,omitempty"`      // Track all A/AAAA records, not only the probed one
+		AllAddresses    bool          `yaml:"all_addresses,omitempty"`    // Probe every resolved address as its own target
+
+		BannerBytes  int    `yaml:"banner_bytes,omitempty"`  // Read up to N bytes after connect (0 disables)
+		ExpectBanner string `yaml:"expect_banner,omitempty"` // Regular expression the banner must match
+
+		SYN bool `yaml:"syn,omitempty"` // Half-open probing over raw sockets; falls back to connect() without CAP_NET_RAW
 	}
 )
 
 // Validate validates the TCP configuration
 func (cfg *TCPConfig) Validate() error {
-	// Basic validation - TCP config is simple, no specific validation needed for now
+	if cfg.ResolveInterval < 0 {
+		return fmt.Errorf("invalid resolve_interval: %v (must not be negative)", cfg.ResolveInterval)
+	}
+	if cfg.BannerBytes < 0 {
+		return fmt.Errorf("invalid banner_bytes: %d (must not be negative)", cfg.BannerBytes)
+	}
+	if cfg.ExpectBanner != "" {
+		if _, err := regexp.Compile(cfg.ExpectBanner); err != nil {
+			return fmt.Errorf("invalid expect_banner pattern: %w", err)
+		}
+	}
+	if cfg.SYN && (cfg.BannerBytes > 0 || cfg.ExpectBanner != "") {
+		return fmt.Errorf("banner checks require a full connection and cannot be used with syn")
+	}
 	return nil
 }
 
 func NewTCPProber(cfg *TCPConfig, prefix string) *TCPProber {
+	var banner *regexp.Regexp
+	if cfg.ExpectBanner != "" {
+		// Invalid patterns are reported by Validate
+		banner, _ = regexp.Compile(cfg.ExpectBanner)
+	}
 	return &TCPProber{
+		banner:   banner,
 		targets:  make(map[string]string),
+		addrs:    make(map[string]string),
+		names:    make(map[string]*resolvedName),
+		groups:   make(map[string]string),
+		families: make(map[string]string),
 		config:   cfg,
 		prefix:   prefix,
 		exitChan: make(chan bool),
@@ -52,6 +99,14 @@ func (p *TCPProber) Accept(target string) error {
 		return fmt.Errorf("invalid TCP target: %w", err)
 	}
 
+	if h, dual := cutDualStack(host); dual {
+		return p.acceptDualStack(h, port)
+	}
+	host, all := cutAllAddresses(host)
+	if (all || p.config.AllAddresses) && !isIPLiteral(host) {
+		return p.acceptAll(host, port)
+	}
+
 	// DNS解決を事前に実行
 	ips, err := net.LookupIP(host)
 	if err != nil {
@@ -64,37 +119,150 @@ func (p *TCPProber) Accept(target string) error {
 
 	p.targets[ipPort] = target // key -> displayName mapping
 
+	// Track the hostname so address changes can be followed
+	if p.config.ResolveInterval > 0 && !isIPLiteral(host) {
+		n := newResolvedName(host, "ip", ip.String(), p.config.ResolveAll)
+		if p.config.ResolveAll {
+			n.refresh() // Record the full set as the baseline
+		}
+		p.names[ipPort] = n
+	}
+
+	return nil
+}
+
+// acceptAll adds one target per address of host, grouped under host:port.
+// Expanded targets are not re-resolved.
+func (p *TCPProber) acceptAll(host, port string) error {
+	addrs, err := lookupAll("ip", host)
+	if err != nil {
+		return err
+	}
+	group := fmt.Sprintf("%s://%s", p.prefix, net.JoinHostPort(host, port))
+	for _, ip := range addrs {
+		ipPort := net.JoinHostPort(ip, port)
+		p.targets[ipPort] = fmt.Sprintf("%s(%s)", group, ip)
+		p.groups[ipPort] = group
+	}
+	return nil
+}
+
+// acceptDualStack adds the first IPv4 and the first IPv6 address of host as a
+// comparison group. Both families must resolve.
+func (p *TCPProber) acceptDualStack(host, port string) error {
+	group := fmt.Sprintf("%s://%s%s", p.prefix, dualStackPrefix, net.JoinHostPort(host, port))
+	for _, family := range []string{"v4", "v6"} {
+		ips, err := lookupIP("ip"+strings.TrimPrefix(family, "v"), host)
+		if err != nil {
+			return fmt.Errorf("failed to resolve '%s' over IP%s: %w", host, family, err)
+		}
+		if len(ips) == 0 {
+			return fmt.Errorf("no IP%s address found for '%s'", family, host)
+		}
+		ipPort := net.JoinHostPort(ips[0].String(), port)
+		p.targets[ipPort] = fmt.Sprintf("%s://%s(%s)", p.prefix, net.JoinHostPort(host, port), ips[0])
+		p.groups[ipPort] = group
+		p.families[ipPort] = family
+	}
 	return nil
 }
 
+// addr returns the ip:port currently probed for key
+func (p *TCPProber) addr(key string) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if addr, ok := p.addrs[key]; ok {
+		return addr
+	}
+	return key
+}
+
+// reresolve looks up tracked hostnames again and moves targets whose address
+// changed. The key stays the same so history continues on one row.
+func (p *TCPProber) reresolve(r chan *Event) {
+	if !p.lookupMu.TryLock() {
+		return // Previous lookup still running
+	}
+	defer p.lookupMu.Unlock()
+
+	for key, n := range p.names {
+		select {
+		case <-p.exitChan:
+			return // Stopping; skip the remaining lookups
+		default:
+		}
+		msg, err := n.refresh()
+		if err != nil || msg == "" {
+			continue // Keep probing the last known address
+		}
+		_, port, _ := net.SplitHostPort(key)
+		p.mu.Lock()
+		p.addrs[key] = net.JoinHostPort(n.current, port)
+		p.mu.Unlock()
+		r <- &Event{
+			Key:         key,
+			DisplayName: p.targets[key],
+			Result:      RESOLVED,
+			SentTime:    time.Now(),
+			Message:     msg,
+		}
+	}
+}
+
 func (p *TCPProber) emitRegistrationEvents(r chan *Event) {
 	for k, v := range p.targets {
 		r <- &Event{
 			Key:         k,
 			DisplayName: v,
 			Result:      REGISTER,
+			Group:       p.groups[k],
+			Family:      p.families[k],
 		}
 	}
 }
 
 func (p *TCPProber) Start(result chan *Event, interval, timeout time.Duration) error {
 	p.emitRegistrationEvents(result)
+	if p.config.SYN {
+		// Without CAP_NET_RAW probes fall back to connect(), which still works
+		if syn, err := newSynProber(p.config.BindToDevice); err == nil {
+			p.syn = syn
+			defer syn.Close()
+		} else {
+			result <- &Event{
+				Key:         p.prefix,
+				DisplayName: p.prefix,
+				Result:      NOTICE,
+				SentTime:    time.Now(),
+				Message:     fmt.Sprintf("SYN probing unavailable, using connect(): %v", err),
+			}
+		}
+	}
 	ticker := time.NewTicker(interval)
+	var resolveC <-chan time.Time
+	if p.config.ResolveInterval > 0 && len(p.names) > 0 {
+		resolveTicker := time.NewTicker(p.config.ResolveInterval)
+		defer resolveTicker.Stop()
+		resolveC = resolveTicker.C
+	}
 	p.wg.Add(1)
 	go func() {
 		defer p.wg.Done()
-		for target := range p.targets {
-			go p.sendProbe(result, target, timeout)
-		}
+		p.probeAll(result, timeout)
 		for {
 			select {
 			case <-p.exitChan:
 				ticker.Stop()
 				return
 			case <-ticker.C:
-				for target := range p.targets {
-					go p.sendProbe(result, target, timeout)
-				}
+				p.probeAll(result, timeout)
+			case <-resolveC:
+				// Tracked so that no RESOLVED event is sent after Start returns
+				p.wg.Add(1)
+				go func() {
+					defer p.wg.Done()
+					p.reresolve(result)
+				}()
 			}
 		}
 	}()
@@ -102,6 +270,18 @@ func (p *TCPProber) Start(result chan *Event, interval, timeout time.Duration) e
 	return nil
 }
 
+// probeAll probes every target concurrently. The probes are tracked so that
+// Start waits for them before closing the raw sockets.
+func (p *TCPProber) probeAll(result chan *Event, timeout time.Duration) {
+	for target := range p.targets {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			p.sendProbe(result, target, timeout)
+		}()
+	}
+}
+
 func (p *TCPProber) Stop() {
 	close(p.exitChan)
 	p.wg.Wait()
@@ -112,30 +292,127 @@ func (p *TCPProber) sendProbe(result chan *Event, target string, timeout time.Du
 	now := time.Now()
 	p.sent(result, target, now)
 
-	// Create dialer with timeout
-	dialer := &net.Dialer{
-		Timeout: timeout,
+	// Create dialer with timeout, bound to the source interface/device if specified
+	dialer, err := newDialer("tcp", p.config.SourceInterface, p.config.BindToDevice, timeout)
+	if err != nil {
+		p.failed(result, target, now, err, nil)
+		return
 	}
 
-	// Set source interface if specified
-	if p.config.SourceInterface != "" {
-		if localAddr, err := p.getSourceAddr(p.config.SourceInterface); err == nil {
-			dialer.LocalAddr = localAddr
-		}
+	remoteAddr := p.addr(target)
+	if p.syn != nil && p.sendSynProbe(result, target, remoteAddr, now, dialer.LocalAddr, timeout) {
+		return
 	}
 
 	// Attempt TCP connection using pre-resolved IP:port
-	conn, err := dialer.Dial("tcp", target)
+	conn, err := dialer.Dial("tcp", remoteAddr)
 	rtt := time.Since(now)
 
+	details := &ProbeDetails{
+		ProbeType: string(TCP),
+		TCP: &TCPDetails{
+			Method:      "connect",
+			RemoteAddr:  remoteAddr,
+			ConnectTime: rtt.Microseconds(),
+		},
+	}
 	if err != nil {
-		p.failed(result, target, now, err)
+		details.TCP.FailureKind = classifyDialError(err)
+		p.failed(result, target, now, err, details)
 		return
 	}
+	defer conn.Close()
+	details.TCP.LocalAddr = conn.LocalAddr().String()
+
+	if p.config.BannerBytes > 0 || p.banner != nil {
+		if err := p.readBanner(conn, now.Add(timeout), details.TCP); err != nil {
+			p.failed(result, target, now, err, details)
+			return
+		}
+	}
+	p.success(result, target, now, rtt, details)
+}
+
+// sendSynProbe probes remoteAddr with a half-open connection. It returns false
+// if the probe could not be sent over a raw socket and connect() should be used.
+func (p *TCPProber) sendSynProbe(result chan *Event, target, remoteAddr string, sentTime time.Time, localAddr net.Addr, timeout time.Duration) bool {
+	dst, err := net.ResolveTCPAddr("tcp", remoteAddr)
+	if err != nil {
+		return false
+	}
+	var src net.IP
+	if la, ok := localAddr.(*net.TCPAddr); ok && (la.IP.To4() == nil) == (dst.IP.To4() == nil) {
+		src = la.IP
+	} else if src, err = sourceIPFor(dst, p.config.BindToDevice); err != nil {
+		return false
+	}
 
-	// Close connection immediately after successful establishment
-	conn.Close()
-	p.success(result, target, now, rtt)
+	flags, localPort, rtt, err := p.syn.probe(src, dst, timeout)
+	if errors.Is(err, errSynUnavailable) {
+		return false
+	}
+	details := &ProbeDetails{
+		ProbeType: string(TCP),
+		TCP: &TCPDetails{
+			Method:      "syn",
+			RemoteAddr:  remoteAddr,
+			LocalAddr:   net.JoinHostPort(src.String(), strconv.Itoa(localPort)),
+			ConnectTime: rtt.Microseconds(),
+		},
+	}
+	switch {
+	case err != nil:
+		details.TCP.FailureKind = classifyDialError(err)
+		p.failed(result, target, sentTime, err, details)
+	case flags&tcpFlagRST != 0:
+		details.TCP.FailureKind = "refused"
+		p.failed(result, target, sentTime, fmt.Errorf("connection refused (RST after %s)", rtt), details)
+	default:
+		p.success(result, target, sentTime, rtt, details)
+	}
+	return true
+}
+
+// readBanner reads the service greeting (e.g. SSH, SMTP) and checks it against
+// expect_banner. The read must finish before deadline.
+func (p *TCPProber) readBanner(conn net.Conn, deadline time.Time, details *TCPDetails) error {
+	size := p.config.BannerBytes
+	if size == 0 {
+		size = defaultBannerBytes
+	}
+	buf := make([]byte, size)
+	start := time.Now()
+	conn.SetReadDeadline(deadline)
+	n, err := conn.Read(buf)
+	details.BannerTime = time.Since(start).Microseconds()
+	details.Banner = formatPayloadContent(buf[:n])
+	if err != nil && n == 0 {
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			details.FailureKind = "timeout"
+		} else {
+			details.FailureKind = "error"
+		}
+		return fmt.Errorf("banner read failed: %w", err)
+	}
+	if p.banner != nil && !p.banner.Match(buf[:n]) {
+		details.FailureKind = "banner_mismatch"
+		return fmt.Errorf("banner %q does not match %q", details.Banner, p.config.ExpectBanner)
+	}
+	return nil
+}
+
+// classifyDialError reports why a connection attempt failed
+func classifyDialError(err error) string {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return "refused"
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	case errors.Is(err, syscall.EHOSTUNREACH), errors.Is(err, syscall.ENETUNREACH):
+		return "no_route"
+	}
+	return "error"
 }
 
 func (p *TCPProber) parseTarget(target string) (host, port string, err error) {
@@ -155,28 +432,6 @@ func (p *TCPProber) parseTarget(target string) (host, port string, err error) {
 	return host, port, nil
 }
 
-func (p *TCPProber) getSourceAddr(interfaceName string) (net.Addr, error) {
-	iface, err := net.InterfaceByName(interfaceName)
-	if err != nil {
-		return nil, err
-	}
-
-	addrs, err := iface.Addrs()
-	if err != nil {
-		return nil, err
-	}
-
-	for _, addr := range addrs {
-		if ipnet, ok := addr.(*net.IPNet); ok && !ipnet.IP.IsLoopback() {
-			if ipnet.IP.To4() != nil {
-				return &net.TCPAddr{IP: ipnet.IP}, nil
-			}
-		}
-	}
-
-	return nil, fmt.Errorf("no suitable address found on interface %s", interfaceName)
-}
-
 func (p *TCPProber) sent(result chan *Event, target string, sentTime time.Time) {
 	displayName := p.targets[target] // Get displayName from targets map
 	result <- &Event{
@@ -189,10 +444,9 @@ func (p *TCPProber) sent(result chan *Event, target string, sentTime time.Time)
 	}
 }
 
-func (p *TCPProber) success(result chan *Event, target string, sentTime time.Time, rtt time.Duration) {
+func (p *TCPProber) success(result chan *Event, target string, sentTime time.Time, rtt time.Duration, details *ProbeDetails) {
 	displayName := p.targets[target] // Get displayName from targets map
-	
-	// TCP only checks connectivity, so no detailed information
+
 	result <- &Event{
 		Key:         target,
 		DisplayName: displayName,
@@ -200,13 +454,14 @@ func (p *TCPProber) success(result chan *Event, target string, sentTime time.Tim
 		SentTime:    sentTime,
 		Rtt:         rtt,
 		Message:     "",
-		Details:     nil, // TCP has no detailed information
+		Details:     details,
 	}
 }
 
-func (p *TCPProber) failed(result chan *Event, target string, sentTime time.Time, err error) {
+func (p *TCPProber) failed(result chan *Event, target string, sentTime time.Time, err error, details *ProbeDetails) {
 	reason := FAILED
-	if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
 		reason = TIMEOUT
 	}
 
@@ -218,5 +473,6 @@ func (p *TCPProber) failed(result chan *Event, target string, sentTime time.Time
 		SentTime:    sentTime,
 		Rtt:         0,
 		Message:     err.Error(),
+		Details:     details,
 	}
 }
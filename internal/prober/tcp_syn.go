@@ -0,0 +1,286 @@
+package prober
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	tcpFlagSYN = 0x02
+	tcpFlagRST = 0x04
+	tcpFlagACK = 0x10
+
+	tcpHeaderLen = 20
+)
+
+// errSynUnavailable is returned when no raw socket exists for the target's address family
+var errSynUnavailable = errors.New("raw socket not available for address family")
+
+type (
+	// synProber sends half-open (SYN) probes over raw sockets. A SYN-ACK means the
+	// port is open and is answered with RST; a RST means the port is closed.
+	synProber struct {
+		conn4   net.PacketConn
+		conn6   net.PacketConn
+		mu      sync.Mutex
+		pending map[synKey]*synPending
+	}
+
+	synKey struct {
+		remote     string
+		remotePort uint16
+		localPort  uint16
+	}
+
+	synPending struct {
+		seq   uint32
+		reply chan synReply
+	}
+
+	synReply struct {
+		flags uint8
+		at    time.Time
+	}
+)
+
+// newSynProber opens raw TCP sockets for IPv4 and IPv6, bound to device if set.
+// It fails only if neither can be opened (typically without CAP_NET_RAW).
+func newSynProber(device string) (*synProber, error) {
+	var lc net.ListenConfig
+	if device != "" {
+		lc.Control = bindToDevice(device)
+	}
+	conn4, err4 := lc.ListenPacket(context.Background(), "ip4:tcp", "0.0.0.0")
+	conn6, err6 := lc.ListenPacket(context.Background(), "ip6:tcp", "::")
+	if err4 != nil && err6 != nil {
+		return nil, fmt.Errorf("failed to open raw TCP socket: %w", err4)
+	}
+	s := &synProber{
+		conn4:   conn4,
+		conn6:   conn6,
+		pending: make(map[synKey]*synPending),
+	}
+	if conn4 != nil {
+		go s.recv(conn4)
+	}
+	if conn6 != nil {
+		go s.recv(conn6)
+	}
+	return s, nil
+}
+
+// Close closes the raw sockets, which also stops the receivers
+func (s *synProber) Close() {
+	if s.conn4 != nil {
+		s.conn4.Close()
+	}
+	if s.conn6 != nil {
+		s.conn6.Close()
+	}
+}
+
+// probe sends a SYN from src to dst and waits for SYN-ACK or RST until timeout.
+// It returns the reply flags, the local port used and the round trip time.
+func (s *synProber) probe(src net.IP, dst *net.TCPAddr, timeout time.Duration) (uint8, int, time.Duration, error) {
+	conn := s.conn4
+	if dst.IP.To4() == nil {
+		conn = s.conn6
+	}
+	if conn == nil {
+		return 0, 0, 0, errSynUnavailable
+	}
+
+	localPort, release, err := reservePort(src)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	defer release()
+
+	seq := rand.Uint32()
+	key, p := s.register(dst, localPort, seq)
+	defer s.unregister(key)
+
+	syn := buildTCPSegment(src, dst.IP, key.localPort, key.remotePort, seq, 0, tcpFlagSYN)
+	start := time.Now()
+	if _, err := conn.WriteTo(syn, &net.IPAddr{IP: dst.IP, Zone: dst.Zone}); err != nil {
+		return 0, int(key.localPort), 0, err
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case r := <-p.reply:
+		if r.flags&(tcpFlagSYN|tcpFlagACK) == tcpFlagSYN|tcpFlagACK {
+			// Tear down the half-open connection
+			rst := buildTCPSegment(src, dst.IP, key.localPort, key.remotePort, seq+1, 0, tcpFlagRST)
+			conn.WriteTo(rst, &net.IPAddr{IP: dst.IP, Zone: dst.Zone})
+		}
+		return r.flags, int(key.localPort), r.at.Sub(start), nil
+	case <-timer.C:
+		return 0, int(key.localPort), 0, fmt.Errorf("no reply to SYN within %v: %w", timeout, os.ErrDeadlineExceeded)
+	}
+}
+
+// register adds a pending probe to dst from localPort, which reservePort
+// keeps exclusive to the probe
+func (s *synProber) register(dst *net.TCPAddr, localPort uint16, seq uint32) (synKey, *synPending) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := &synPending{seq: seq, reply: make(chan synReply, 1)}
+	key := synKey{
+		remote:     dst.IP.String(),
+		remotePort: uint16(dst.Port),
+		localPort:  localPort,
+	}
+	s.pending[key] = p
+	return key, p
+}
+
+// reservePort binds a TCP socket to an ephemeral port of src, so that no local
+// socket can use the port while a SYN is sent from it. The socket neither
+// connects nor listens; release closes it.
+func reservePort(src net.IP) (uint16, func(), error) {
+	family, sa := syscall.AF_INET, syscall.Sockaddr(nil)
+	if ip4 := src.To4(); ip4 != nil {
+		sa4 := &syscall.SockaddrInet4{}
+		copy(sa4.Addr[:], ip4)
+		sa = sa4
+	} else {
+		sa6 := &syscall.SockaddrInet6{}
+		copy(sa6.Addr[:], src.To16())
+		family, sa = syscall.AF_INET6, sa6
+	}
+	fd, err := syscall.Socket(family, syscall.SOCK_STREAM, syscall.IPPROTO_TCP)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to reserve a local port: %w", err)
+	}
+	release := func() { syscall.Close(fd) }
+	if err := syscall.Bind(fd, sa); err != nil {
+		release()
+		return 0, nil, fmt.Errorf("failed to reserve a local port: %w", err)
+	}
+	bound, err := syscall.Getsockname(fd)
+	if err != nil {
+		release()
+		return 0, nil, fmt.Errorf("failed to reserve a local port: %w", err)
+	}
+	switch a := bound.(type) {
+	case *syscall.SockaddrInet4:
+		return uint16(a.Port), release, nil
+	case *syscall.SockaddrInet6:
+		return uint16(a.Port), release, nil
+	}
+	release()
+	return 0, nil, fmt.Errorf("failed to reserve a local port: unexpected address %T", bound)
+}
+
+func (s *synProber) unregister(key synKey) {
+	s.mu.Lock()
+	delete(s.pending, key)
+	s.mu.Unlock()
+}
+
+// recv matches incoming TCP segments to pending probes until conn is closed
+func (s *synProber) recv(conn net.PacketConn) {
+	buf := make([]byte, maxPacketSize)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		at := time.Now()
+		if n < tcpHeaderLen {
+			continue
+		}
+		ipAddr, ok := addr.(*net.IPAddr)
+		if !ok {
+			continue
+		}
+		key := synKey{
+			remote:     ipAddr.IP.String(),
+			remotePort: binary.BigEndian.Uint16(buf[0:2]),
+			localPort:  binary.BigEndian.Uint16(buf[2:4]),
+		}
+		ack := binary.BigEndian.Uint32(buf[8:12])
+		flags := buf[13]
+		if flags&(tcpFlagRST|tcpFlagSYN) == 0 {
+			continue
+		}
+
+		s.mu.Lock()
+		p, exists := s.pending[key]
+		s.mu.Unlock()
+		if !exists || (flags&tcpFlagACK != 0 && ack != p.seq+1) {
+			continue
+		}
+		select {
+		case p.reply <- synReply{flags: flags, at: at}:
+		default:
+		}
+	}
+}
+
+// buildTCPSegment builds a TCP header (no options, no payload) with a valid checksum
+func buildTCPSegment(src, dst net.IP, srcPort, dstPort uint16, seq, ack uint32, flags uint8) []byte {
+	b := make([]byte, tcpHeaderLen)
+	binary.BigEndian.PutUint16(b[0:2], srcPort)
+	binary.BigEndian.PutUint16(b[2:4], dstPort)
+	binary.BigEndian.PutUint32(b[4:8], seq)
+	binary.BigEndian.PutUint32(b[8:12], ack)
+	b[12] = (tcpHeaderLen / 4) << 4
+	b[13] = flags
+	binary.BigEndian.PutUint16(b[14:16], 65535) // Window
+	binary.BigEndian.PutUint16(b[16:18], tcpChecksum(src, dst, b))
+	return b
+}
+
+// tcpChecksum computes the TCP checksum including the IPv4/IPv6 pseudo header
+func tcpChecksum(src, dst net.IP, seg []byte) uint16 {
+	var sum uint32
+	add := func(b []byte) {
+		for i := 0; i+1 < len(b); i += 2 {
+			sum += uint32(b[i])<<8 | uint32(b[i+1])
+		}
+		if len(b)%2 == 1 {
+			sum += uint32(b[len(b)-1]) << 8
+		}
+	}
+
+	if src4, dst4 := src.To4(), dst.To4(); src4 != nil && dst4 != nil {
+		add(src4)
+		add(dst4)
+	} else {
+		add(src.To16())
+		add(dst.To16())
+	}
+	sum += 6 // Protocol: TCP
+	sum += uint32(len(seg))
+	add(seg)
+
+	for sum>>16 != 0 {
+		sum = sum&0xffff + sum>>16
+	}
+	return ^uint16(sum)
+}
+
+// sourceIPFor returns the local address the kernel would use to reach dst via device
+func sourceIPFor(dst *net.TCPAddr, device string) (net.IP, error) {
+	d, err := newDialer("udp", "", device, 0)
+	if err != nil {
+		return nil, err
+	}
+	c, err := d.Dial("udp", (&net.UDPAddr{IP: dst.IP, Port: dst.Port, Zone: dst.Zone}).String())
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+	return c.LocalAddr().(*net.UDPAddr).IP, nil
+}
@@ -0,0 +1,187 @@
+package prober
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// probeOnce runs a single TCP probe against addr and returns the final event
+func probeOnce(t *testing.T, cfg *TCPConfig, addr string) *Event {
+	t.Helper()
+	return probeWith(NewTCPProber(cfg, "tcp"), addr)
+}
+
+func probeWith(p *TCPProber, addr string) *Event {
+	p.targets[addr] = "tcp://" + addr
+
+	events := make(chan *Event, 10)
+	p.sendProbe(events, addr, time.Second)
+	for e := range events {
+		if e.Result != SENT {
+			return e
+		}
+	}
+	return nil
+}
+
+func TestTCPProbeDetails(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			c.Write([]byte("SSH-2.0-OpenSSH_9.6\r\n"))
+			c.Close()
+		}
+	}()
+	addr := ln.Addr().String()
+
+	e := probeOnce(t, &TCPConfig{ExpectBanner: "^SSH-2\\.0-"}, addr)
+	if e.Result != SUCCESS {
+		t.Fatalf("Expected success, got %v: %s", e.Result, e.Message)
+	}
+	if e.Details == nil || e.Details.TCP == nil {
+		t.Fatal("Expected TCP details")
+	}
+	d := e.Details.TCP
+	if d.RemoteAddr != addr || d.LocalAddr == "" {
+		t.Errorf("Unexpected addresses: remote=%s local=%s", d.RemoteAddr, d.LocalAddr)
+	}
+	if d.Banner != "SSH-2.0-OpenSSH_9.6\\x0d\\x0a" {
+		t.Errorf("Unexpected banner: %q", d.Banner)
+	}
+
+	e = probeOnce(t, &TCPConfig{ExpectBanner: "^220 "}, addr)
+	if e.Result != FAILED || e.Details.TCP.FailureKind != "banner_mismatch" {
+		t.Errorf("Expected banner mismatch failure, got %v (%s)", e.Result, e.Message)
+	}
+}
+
+func TestTCPProbeRefused(t *testing.T) {
+	// Reserve a port and close it so the connection is refused
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	e := probeOnce(t, &TCPConfig{}, addr)
+	if e.Result != FAILED {
+		t.Fatalf("Expected failure, got %v", e.Result)
+	}
+	if e.Details == nil || e.Details.TCP == nil || e.Details.TCP.FailureKind != "refused" {
+		t.Errorf("Expected failure kind refused, got %+v", e.Details)
+	}
+}
+
+func TestTCPConfigValidateBanner(t *testing.T) {
+	if err := (&TCPConfig{ExpectBanner: "("}).Validate(); err == nil {
+		t.Error("Expected invalid expect_banner to fail validation")
+	}
+	if err := (&TCPConfig{BannerBytes: -1}).Validate(); err == nil {
+		t.Error("Expected negative banner_bytes to fail validation")
+	}
+}
+
+func TestTCPChecksum(t *testing.T) {
+	src, dst := net.ParseIP("192.0.2.1"), net.ParseIP("198.51.100.2")
+	seg := buildTCPSegment(src, dst, 40000, 443, 1, 0, tcpFlagSYN)
+	// A segment with a valid checksum sums to zero
+	if sum := tcpChecksum(src, dst, seg); sum != 0 {
+		t.Errorf("Expected verified checksum 0, got %#04x", sum)
+	}
+
+	src6, dst6 := net.ParseIP("2001:db8::1"), net.ParseIP("2001:db8::2")
+	seg = buildTCPSegment(src6, dst6, 40000, 443, 1, 0, tcpFlagSYN)
+	if sum := tcpChecksum(src6, dst6, seg); sum != 0 {
+		t.Errorf("Expected verified IPv6 checksum 0, got %#04x", sum)
+	}
+}
+
+func TestTCPSynProbe(t *testing.T) {
+	syn, err := newSynProber("")
+	if err != nil {
+		t.Skip("Raw sockets not available:", err)
+	}
+	defer syn.Close()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	addr := ln.Addr().String()
+
+	p := NewTCPProber(&TCPConfig{SYN: true}, "tcp")
+	p.syn = syn
+	e := probeWith(p, addr)
+	if e.Result != SUCCESS {
+		t.Fatalf("Expected SYN-ACK success, got %v: %s", e.Result, e.Message)
+	}
+	if e.Details.TCP.Method != "syn" {
+		t.Errorf("Expected syn method, got %s", e.Details.TCP.Method)
+	}
+
+	// Closed port answers with RST
+	ln.Close()
+	e = probeWith(p, addr)
+	if e.Result != FAILED || e.Details.TCP.FailureKind != "refused" {
+		t.Errorf("Expected refused failure, got %v (%s)", e.Result, e.Message)
+	}
+}
+
+func TestTCPSynFallback(t *testing.T) {
+	// Raw sockets cannot bind to a missing device, so probing falls back to connect()
+	p := NewTCPProber(&TCPConfig{SYN: true, BindToDevice: "mping-missing0"}, "tcp")
+	events := make(chan *Event, 10)
+	done := make(chan error, 1)
+	go func() { done <- p.Start(events, time.Hour, time.Second) }()
+
+	select {
+	case e := <-events:
+		if e.Result != NOTICE || e.Key != "tcp" || !strings.Contains(e.Message, "using connect()") {
+			t.Errorf("Expected a fallback notice, got %v: %s", e.Result, e.Message)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected a fallback notice")
+	}
+	p.Stop()
+	if err := <-done; err != nil {
+		t.Errorf("Start() error = %v", err)
+	}
+	if p.syn != nil {
+		t.Error("Expected connect() probing")
+	}
+}
+
+func TestReservePort(t *testing.T) {
+	port, release, err := reservePort(net.ParseIP("127.0.0.1"))
+	if err != nil {
+		t.Fatalf("reservePort() error = %v", err)
+	}
+	if port == 0 {
+		t.Fatal("Expected an ephemeral port")
+	}
+
+	// No other socket can take the port while it is reserved
+	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(int(port)))
+	if ln, err := net.Listen("tcp", addr); err == nil {
+		ln.Close()
+		t.Fatalf("Expected port %d to be in use", port)
+	}
+	release()
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatalf("Expected port %d to be free after release: %v", port, err)
+	}
+	ln.Close()
+}
@@ -0,0 +1,187 @@
+//go:build linux
+
+package prober
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/ipv4"
+)
+
+// listenICMPSocket opens an ICMP endpoint, bound to device if set, with kernel
+// receive timestamps enabled. Datagram endpoints ("udp4"/"udp6") are ICMP sockets
+// rather than UDP, so they are created directly.
+func listenICMPSocket(network, address, device string) (net.PacketConn, error) {
+	if device != "" {
+		c, err := listenICMPDevice(network, address, device)
+		if err != nil {
+			return nil, err
+		}
+		if err := enableRxTimestamps(c); err != nil {
+			c.Close()
+			return nil, err
+		}
+		return c, nil
+	}
+
+	var family, proto int
+	switch network {
+	case "udp4":
+		family, proto = syscall.AF_INET, syscall.IPPROTO_ICMP
+	case "udp6":
+		family, proto = syscall.AF_INET6, syscall.IPPROTO_ICMPV6
+	default:
+		lc := net.ListenConfig{Control: func(network, address string, c syscall.RawConn) error {
+			var serr error
+			if err := c.Control(func(fd uintptr) {
+				serr = setRxTimestamps(int(fd))
+			}); err != nil {
+				return err
+			}
+			return serr
+		}}
+		return lc.ListenPacket(context.Background(), network, address)
+	}
+
+	var ip net.IP
+	if address != "" {
+		if ip = net.ParseIP(address); ip == nil {
+			return nil, fmt.Errorf("invalid source address: %s", address)
+		}
+	}
+	var sa syscall.Sockaddr
+	if family == syscall.AF_INET {
+		sa4 := &syscall.SockaddrInet4{}
+		if ip != nil {
+			copy(sa4.Addr[:], ip.To4())
+		}
+		sa = sa4
+	} else {
+		sa6 := &syscall.SockaddrInet6{}
+		if ip != nil {
+			copy(sa6.Addr[:], ip.To16())
+		}
+		sa = sa6
+	}
+
+	s, err := syscall.Socket(family, syscall.SOCK_DGRAM|syscall.SOCK_CLOEXEC, proto)
+	if err != nil {
+		return nil, os.NewSyscallError("socket", err)
+	}
+	if err := setRxTimestamps(s); err != nil {
+		syscall.Close(s)
+		return nil, err
+	}
+	if err := syscall.Bind(s, sa); err != nil {
+		syscall.Close(s)
+		return nil, os.NewSyscallError("bind", err)
+	}
+	f := os.NewFile(uintptr(s), "datagram-oriented icmp")
+	defer f.Close()
+	return net.FilePacketConn(f)
+}
+
+// enableRxTimestamps enables SO_TIMESTAMPNS on an open endpoint
+func enableRxTimestamps(c net.PacketConn) error {
+	sc, ok := c.(syscall.Conn)
+	if !ok {
+		return nil
+	}
+	rc, err := sc.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var serr error
+	if err := rc.Control(func(fd uintptr) {
+		serr = setRxTimestamps(int(fd))
+	}); err != nil {
+		return err
+	}
+	return serr
+}
+
+func setRxTimestamps(fd int) error {
+	if err := syscall.SetsockoptInt(fd, syscall.SOL_SOCKET, syscall.SO_TIMESTAMPNS, 1); err != nil {
+		return os.NewSyscallError("setsockopt", err)
+	}
+	return nil
+}
+
+// readPacket reads a packet from c along with its kernel receive timestamp.
+// Raw IPv4 sockets return the IP header from ReadMsgIP, so it is stripped here
+// as ReadFrom would. If no timestamp is available, the time after the read is used.
+func readPacket(c net.PacketConn, b, oob []byte) (int, net.Addr, time.Time, error) {
+	var (
+		n, oobn int
+		peer    net.Addr
+		err     error
+	)
+	switch conn := c.(type) {
+	case *net.IPConn:
+		var addr *net.IPAddr
+		n, oobn, _, addr, err = conn.ReadMsgIP(b, oob)
+		if err == nil && addr.IP.To4() != nil {
+			n = stripIPv4Header(b, n)
+		}
+		peer = addr
+	case *net.UDPConn:
+		var addr *net.UDPAddr
+		n, oobn, _, addr, err = conn.ReadMsgUDP(b, oob)
+		peer = addr
+	default:
+		n, peer, err = c.ReadFrom(b)
+		return n, peer, time.Now(), err
+	}
+	now := time.Now()
+	if err != nil {
+		return n, peer, now, err
+	}
+	if at, ok := parseRxTimestamp(oob[:oobn]); ok {
+		return n, peer, at, nil
+	}
+	return n, peer, now, nil
+}
+
+// stripIPv4Header removes the IPv4 header from a packet read on a raw socket
+func stripIPv4Header(b []byte, n int) int {
+	if n < ipv4.HeaderLen || b[0]>>4 != 4 {
+		return n
+	}
+	l := int(b[0]&0x0f) << 2
+	if l < ipv4.HeaderLen || n < l {
+		return n
+	}
+	copy(b, b[l:n])
+	return n - l
+}
+
+// parseRxTimestamp extracts an SCM_TIMESTAMPNS control message
+func parseRxTimestamp(oob []byte) (time.Time, bool) {
+	msgs, err := syscall.ParseSocketControlMessage(oob)
+	if err != nil {
+		return time.Time{}, false
+	}
+	for _, m := range msgs {
+		if m.Header.Level != syscall.SOL_SOCKET || m.Header.Type != syscall.SCM_TIMESTAMPNS {
+			continue
+		}
+		// struct timespec: two native longs
+		switch len(m.Data) {
+		case 16:
+			sec := int64(binary.NativeEndian.Uint64(m.Data[0:8]))
+			nsec := int64(binary.NativeEndian.Uint64(m.Data[8:16]))
+			return time.Unix(sec, nsec), true
+		case 8:
+			sec := int32(binary.NativeEndian.Uint32(m.Data[0:4]))
+			nsec := int32(binary.NativeEndian.Uint32(m.Data[4:8]))
+			return time.Unix(int64(sec), int64(nsec)), true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,62 @@
+//go:build linux
+
+package prober
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestReadPacketKernelTimestamp(t *testing.T) {
+	c, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Skipf("cannot listen: %v", err)
+	}
+	defer c.Close()
+	rc, err := c.SyscallConn()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var serr error
+	rc.Control(func(fd uintptr) { serr = setRxTimestamps(int(fd)) })
+	if serr != nil {
+		t.Fatalf("setRxTimestamps failed: %v", serr)
+	}
+
+	sent := time.Now()
+	if _, err := c.WriteTo([]byte("ping"), c.LocalAddr()); err != nil {
+		t.Fatal(err)
+	}
+	// The packet waits in the socket buffer; a kernel timestamp predates the read
+	time.Sleep(50 * time.Millisecond)
+
+	b := make([]byte, 64)
+	oob := make([]byte, 128)
+	n, _, at, err := readPacket(c, b, oob)
+	if err != nil {
+		t.Fatalf("readPacket failed: %v", err)
+	}
+	if string(b[:n]) != "ping" {
+		t.Errorf("payload = %q, want ping", b[:n])
+	}
+	if d := at.Sub(sent); d < 0 || d > 25*time.Millisecond {
+		t.Errorf("receive time %v after send, want kernel timestamp close to send", d)
+	}
+}
+
+func TestStripIPv4Header(t *testing.T) {
+	b := make([]byte, 28)
+	b[0] = 0x45 // Version 4, IHL 5
+	copy(b[20:], []byte{8, 0, 0, 0, 1, 2, 3, 4})
+	n := stripIPv4Header(b, len(b))
+	if n != 8 || b[0] != 8 || b[7] != 4 {
+		t.Errorf("stripIPv4Header = %d %v, want ICMP message of 8 bytes", n, b[:n])
+	}
+
+	// Not an IPv4 header: left unchanged
+	icmpOnly := []byte{0, 0, 0, 0, 1, 2, 3, 4}
+	if n := stripIPv4Header(icmpOnly, len(icmpOnly)); n != len(icmpOnly) {
+		t.Errorf("stripIPv4Header on non-IPv4 data = %d, want %d", n, len(icmpOnly))
+	}
+}
@@ -0,0 +1,29 @@
+//go:build !linux
+
+package prober
+
+import (
+	"net"
+	"time"
+
+	"golang.org/x/net/icmp"
+)
+
+// listenICMPSocket opens an ICMP endpoint. Binding to a device is not supported.
+func listenICMPSocket(network, address, device string) (net.PacketConn, error) {
+	if device != "" {
+		return listenICMPDevice(network, address, device)
+	}
+	c, err := icmp.ListenPacket(network, address)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// readPacket reads a packet from c. Kernel timestamps are not used, so the
+// receive time is taken right after the read.
+func readPacket(c net.PacketConn, b, oob []byte) (int, net.Addr, time.Time, error) {
+	n, peer, err := c.ReadFrom(b)
+	return n, peer, time.Now(), err
+}
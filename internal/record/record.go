@@ -0,0 +1,132 @@
+// Package record writes probe events to a file and plays them back later.
+//
+// A recording is newline-delimited JSON: a Header line followed by one line
+// per event with the time it was recorded.
+package record
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/servak/mping/internal/prober"
+)
+
+// Version is the format version of recordings; other versions are rejected
+const Version = 1
+
+// Header is the first line of a recording
+type Header struct {
+	Version  int           `json:"version"`
+	Started  time.Time     `json:"started"`
+	Interval time.Duration `json:"interval"`
+	Timeout  time.Duration `json:"timeout"`
+}
+
+// entry is one recorded event
+type entry struct {
+	At    time.Time     `json:"at"`
+	Event *prober.Event `json:"event"`
+}
+
+// Recorder writes events to a recording as they pass through
+type Recorder struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	err error
+}
+
+// NewRecorder writes the header of a recording of probes sent every interval to w
+func NewRecorder(w io.Writer, interval, timeout time.Duration) (*Recorder, error) {
+	r := &Recorder{enc: json.NewEncoder(w)}
+	h := Header{Version: Version, Started: time.Now(), Interval: interval, Timeout: timeout}
+	if err := r.enc.Encode(h); err != nil {
+		return nil, fmt.Errorf("failed to write recording: %w", err)
+	}
+	return r, nil
+}
+
+// WriteAll records every event of in until in is closed
+func (r *Recorder) WriteAll(in <-chan *prober.Event) {
+	for e := range in {
+		r.Write(e)
+	}
+}
+
+// Write records e. After the first error nothing more is written; Err returns it.
+func (r *Recorder) Write(e *prober.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return
+	}
+	if err := r.enc.Encode(entry{At: time.Now(), Event: e}); err != nil {
+		r.err = fmt.Errorf("failed to write recording: %w", err)
+	}
+}
+
+// Err returns the first write error, if any
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Player reads a recording
+type Player struct {
+	Header Header
+	dec    *json.Decoder
+}
+
+// NewPlayer reads the header of the recording in r
+func NewPlayer(r io.Reader) (*Player, error) {
+	p := &Player{dec: json.NewDecoder(r)}
+	if err := p.dec.Decode(&p.Header); err != nil {
+		return nil, fmt.Errorf("failed to read recording header: %w", err)
+	}
+	if p.Header.Version != Version {
+		return nil, fmt.Errorf("unsupported recording version %d (expected %d)", p.Header.Version, Version)
+	}
+	return p, nil
+}
+
+// Play sends the recorded events to out with the recorded pacing sped up by
+// speed; a speed of 0 sends them as fast as possible. It returns at the end of
+// the recording or when ctx is done, and does not close out.
+func (p *Player) Play(ctx context.Context, speed float64, out chan<- *prober.Event) error {
+	start := time.Now()
+	var first time.Time
+	for {
+		var e entry
+		if err := p.dec.Decode(&e); errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("failed to read recording: %w", err)
+		}
+		if e.Event == nil {
+			continue
+		}
+		if first.IsZero() {
+			first = e.At
+		}
+		if speed > 0 {
+			due := start.Add(time.Duration(float64(e.At.Sub(first)) / speed))
+			if wait := time.Until(due); wait > 0 {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-time.After(wait):
+				}
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case out <- e.Event:
+		}
+	}
+}
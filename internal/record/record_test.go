@@ -0,0 +1,141 @@
+package record
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/servak/mping/internal/prober"
+)
+
+func TestRecordAndPlay(t *testing.T) {
+	var buf bytes.Buffer
+	rec, err := NewRecorder(&buf, time.Second, 500*time.Millisecond)
+	if err != nil {
+		t.Fatalf("NewRecorder() error = %v", err)
+	}
+	sent := time.Now().Add(-time.Second).Round(0)
+	recorded := []*prober.Event{
+		{Key: "192.0.2.1", DisplayName: "example.com(192.0.2.1)", Result: prober.REGISTER, Group: "example.com", Family: "v4"},
+		{Key: "192.0.2.1", Result: prober.SUCCESS, SentTime: sent, Rtt: 12 * time.Millisecond,
+			Details: &prober.ProbeDetails{ProbeType: "icmpv4", ICMP: &prober.ICMPDetails{Sequence: 3, PacketSize: 64}}},
+		{Key: "192.0.2.1", Result: prober.TIMEOUT, SentTime: sent, Message: "timeout"},
+	}
+	in := make(chan *prober.Event, len(recorded))
+	for _, e := range recorded {
+		in <- e
+	}
+	close(in)
+	rec.WriteAll(in)
+	if rec.Err() != nil {
+		t.Fatalf("WriteAll() error = %v", rec.Err())
+	}
+	if !strings.Contains(buf.String(), `"result":"TIMEOUT"`) {
+		t.Errorf("Expected results to be recorded by name:\n%s", buf.String())
+	}
+
+	player, err := NewPlayer(&buf)
+	if err != nil {
+		t.Fatalf("NewPlayer() error = %v", err)
+	}
+	if player.Header.Interval != time.Second || player.Header.Timeout != 500*time.Millisecond {
+		t.Errorf("Unexpected header: %+v", player.Header)
+	}
+	out := make(chan *prober.Event, len(recorded))
+	if err := player.Play(context.Background(), 0, out); err != nil {
+		t.Fatalf("Play() error = %v", err)
+	}
+	close(out)
+	var played []*prober.Event
+	for e := range out {
+		played = append(played, e)
+	}
+	if len(played) != len(recorded) {
+		t.Fatalf("Expected %d events, got %d", len(recorded), len(played))
+	}
+	for i, e := range played {
+		want := recorded[i]
+		if e.Key != want.Key || e.Result != want.Result || e.Rtt != want.Rtt || !e.SentTime.Equal(want.SentTime) || e.Message != want.Message {
+			t.Errorf("Event %d: expected %+v, got %+v", i, want, e)
+		}
+	}
+	if played[0].Group != "example.com" || played[0].Family != "v4" {
+		t.Errorf("Registration not replayed: %+v", played[0])
+	}
+	if d := played[1].Details; d == nil || d.ICMP == nil || d.ICMP.Sequence != 3 || d.ICMP.PacketSize != 64 {
+		t.Errorf("Details not replayed: %+v", d)
+	}
+}
+
+func TestPlaySpeed(t *testing.T) {
+	start := time.Now()
+	lines := []any{
+		Header{Version: Version, Started: start, Interval: time.Second},
+		entry{At: start, Event: &prober.Event{Key: "a", Result: prober.SENT}},
+		entry{At: start.Add(time.Second), Event: &prober.Event{Key: "a", Result: prober.SUCCESS}},
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, l := range lines {
+		enc.Encode(l)
+	}
+	player, err := NewPlayer(&buf)
+	if err != nil {
+		t.Fatalf("NewPlayer() error = %v", err)
+	}
+
+	out := make(chan *prober.Event, 2)
+	begin := time.Now()
+	if err := player.Play(context.Background(), 10, out); err != nil {
+		t.Fatalf("Play() error = %v", err)
+	}
+	if elapsed := time.Since(begin); elapsed < 90*time.Millisecond || elapsed > 900*time.Millisecond {
+		t.Errorf("Expected one second played back in about 100ms at 10x, took %v", elapsed)
+	}
+	if len(out) != 2 {
+		t.Errorf("Expected 2 events, got %d", len(out))
+	}
+}
+
+func TestPlayCancel(t *testing.T) {
+	start := time.Now()
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.Encode(Header{Version: Version, Started: start})
+	enc.Encode(entry{At: start, Event: &prober.Event{Key: "a", Result: prober.SENT}})
+	enc.Encode(entry{At: start.Add(time.Hour), Event: &prober.Event{Key: "a", Result: prober.SUCCESS}})
+	player, err := NewPlayer(&buf)
+	if err != nil {
+		t.Fatalf("NewPlayer() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	out := make(chan *prober.Event, 2)
+	if err := player.Play(ctx, 1, out); err != context.DeadlineExceeded {
+		t.Errorf("Expected the context error, got %v", err)
+	}
+	if len(out) != 1 {
+		t.Errorf("Expected only the first event before cancelling, got %d", len(out))
+	}
+}
+
+func TestNewPlayerErrors(t *testing.T) {
+	if _, err := NewPlayer(strings.NewReader(`{"version": 99}`)); err == nil || !strings.Contains(err.Error(), "unsupported recording version 99") {
+		t.Errorf("Expected a version error, got %v", err)
+	}
+	if _, err := NewPlayer(strings.NewReader("")); err == nil {
+		t.Error("Expected an error for an empty recording")
+	}
+
+	player, err := NewPlayer(strings.NewReader(`{"version": 1}` + "\n" + `{"event": {"key": "a", "result": "BOGUS"}}`))
+	if err != nil {
+		t.Fatalf("NewPlayer() error = %v", err)
+	}
+	if err := player.Play(context.Background(), 0, make(chan *prober.Event, 1)); err == nil {
+		t.Error("Expected an error for an unknown result")
+	}
+}
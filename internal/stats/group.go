@@ -0,0 +1,498 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// GroupMetrics places targets expanded from the same hostname together.
+// Each group is preceded by an aggregate row at the position of its first
+// member, so the existing sort order is kept for both groups and members.
+func GroupMetrics(ms []Metrics) []Metrics {
+	members := make(map[string][]Metrics)
+	for _, m := range ms {
+		if g := m.GetGroup(); g != "" {
+			members[g] = append(members[g], m)
+		}
+	}
+	if len(members) == 0 {
+		return ms
+	}
+
+	res := make([]Metrics, 0, len(ms)+len(members))
+	seen := make(map[string]bool, len(members))
+	for _, m := range ms {
+		g := m.GetGroup()
+		if g == "" {
+			res = append(res, m)
+			continue
+		}
+		if seen[g] {
+			continue
+		}
+		seen[g] = true
+		if c := newComparisonMetrics(g, members[g]); c != nil {
+			res = append(res, c, c.v4, c.v6)
+			continue
+		}
+		res = append(res, newGroupMetrics(g, members[g]))
+		res = append(res, members[g]...)
+	}
+	return res
+}
+
+// Comparison is implemented by aggregate rows of dual-stack targets.
+// Deltas are IPv6 minus IPv4, so positive values mean IPv6 is worse.
+type Comparison interface {
+	Metrics
+	GetLossDelta() float64
+	GetLastRTTDelta() time.Duration
+	GetAverageRTTDelta() time.Duration
+	GetMinimumRTTDelta() time.Duration
+	GetMaximumRTTDelta() time.Duration
+}
+
+// comparisonMetrics aggregates a group of exactly one IPv4 and one IPv6 target
+type comparisonMetrics struct {
+	*groupMetrics
+	v4, v6 Metrics
+}
+
+// newComparisonMetrics returns nil unless members are one v4 and one v6 target
+func newComparisonMetrics(group string, members []Metrics) *comparisonMetrics {
+	if len(members) != 2 {
+		return nil
+	}
+	c := &comparisonMetrics{
+		groupMetrics: &groupMetrics{
+			group:   group,
+			name:    fmt.Sprintf("%s [v6-v4]", group),
+			members: members,
+		},
+	}
+	for _, m := range members {
+		switch m.GetFamily() {
+		case "v4":
+			c.v4 = m
+		case "v6":
+			c.v6 = m
+		}
+	}
+	if c.v4 == nil || c.v6 == nil {
+		return nil
+	}
+	return c
+}
+
+func (c *comparisonMetrics) GetLossDelta() float64 {
+	return c.v6.GetLoss() - c.v4.GetLoss()
+}
+
+func (c *comparisonMetrics) GetLastRTTDelta() time.Duration {
+	return rttDelta(c.v4.GetLastRTT(), c.v6.GetLastRTT())
+}
+
+func (c *comparisonMetrics) GetAverageRTTDelta() time.Duration {
+	return rttDelta(c.v4.GetAverageRTT(), c.v6.GetAverageRTT())
+}
+
+func (c *comparisonMetrics) GetMinimumRTTDelta() time.Duration {
+	return rttDelta(c.v4.GetMinimumRTT(), c.v6.GetMinimumRTT())
+}
+
+func (c *comparisonMetrics) GetMaximumRTTDelta() time.Duration {
+	return rttDelta(c.v4.GetMaximumRTT(), c.v6.GetMaximumRTT())
+}
+
+// rttDelta returns v6 - v4, or 0 if either side has not been measured yet
+func rttDelta(v4, v6 time.Duration) time.Duration {
+	if v4 == 0 || v6 == 0 {
+		return 0
+	}
+	return v6 - v4
+}
+
+// groupMetrics is the aggregate of all targets in a group
+type groupMetrics struct {
+	group   string
+	name    string
+	members []Metrics
+}
+
+func newGroupMetrics(group string, members []Metrics) *groupMetrics {
+	return &groupMetrics{
+		group:   group,
+		name:    fmt.Sprintf("%s [%d addrs]", group, len(members)),
+		members: members,
+	}
+}
+
+func (g *groupMetrics) GetKey() string {
+	return g.group
+}
+
+func (g *groupMetrics) GetName() string {
+	return g.name
+}
+
+// GetGroup returns "" because the aggregate row is a top-level row
+func (g *groupMetrics) GetGroup() string {
+	return ""
+}
+
+func (g *groupMetrics) GetFamily() string {
+	return ""
+}
+
+func (g *groupMetrics) GetTotal() int {
+	total := 0
+	for _, m := range g.members {
+		total += m.GetTotal()
+	}
+	return total
+}
+
+func (g *groupMetrics) GetSuccessful() int {
+	successful := 0
+	for _, m := range g.members {
+		successful += m.GetSuccessful()
+	}
+	return successful
+}
+
+func (g *groupMetrics) GetFailed() int {
+	failed := 0
+	for _, m := range g.members {
+		failed += m.GetFailed()
+	}
+	return failed
+}
+
+func (g *groupMetrics) GetLoss() float64 {
+	successful, failed := g.GetSuccessful(), g.GetFailed()
+	if successful+failed == 0 {
+		return 0
+	}
+	return float64(failed) / float64(successful+failed) * 100
+}
+
+// GetLastRTT returns the RTT of the most recently successful member
+func (g *groupMetrics) GetLastRTT() time.Duration {
+	var last time.Time
+	var rtt time.Duration
+	for _, m := range g.members {
+		if t := m.GetLastSuccTime(); t.After(last) {
+			last = t
+			rtt = m.GetLastRTT()
+		}
+	}
+	return rtt
+}
+
+// GetAverageRTT returns the average weighted by each member's successes
+func (g *groupMetrics) GetAverageRTT() time.Duration {
+	var total time.Duration
+	successful := 0
+	for _, m := range g.members {
+		total += m.GetAverageRTT() * time.Duration(m.GetSuccessful())
+		successful += m.GetSuccessful()
+	}
+	if successful == 0 {
+		return 0
+	}
+	return total / time.Duration(successful)
+}
+
+func (g *groupMetrics) GetMinimumRTT() time.Duration {
+	var minimum time.Duration
+	for _, m := range g.members {
+		if rtt := m.GetMinimumRTT(); rtt != 0 && (minimum == 0 || rtt < minimum) {
+			minimum = rtt
+		}
+	}
+	return minimum
+}
+
+func (g *groupMetrics) GetMaximumRTT() time.Duration {
+	var maximum time.Duration
+	for _, m := range g.members {
+		if rtt := m.GetMaximumRTT(); rtt > maximum {
+			maximum = rtt
+		}
+	}
+	return maximum
+}
+
+// GetJitter returns the members' jitter weighted by their successes
+func (g *groupMetrics) GetJitter() time.Duration {
+	var total time.Duration
+	successful := 0
+	for _, m := range g.members {
+		total += m.GetJitter() * time.Duration(m.GetSuccessful())
+		successful += m.GetSuccessful()
+	}
+	if successful == 0 {
+		return 0
+	}
+	return total / time.Duration(successful)
+}
+
+// GetStdDev returns the standard deviation over all members' RTTs
+func (g *groupMetrics) GetStdDev() time.Duration {
+	return g.pooledStdDev(Metrics.GetSuccessful, Metrics.GetAverageRTT, Metrics.GetStdDev)
+}
+
+// pooledStdDev combines the members' standard deviations from each member's
+// count, mean and standard deviation
+func (g *groupMetrics) pooledStdDev(count func(Metrics) int, mean, stdDev func(Metrics) time.Duration) time.Duration {
+	var n, sum, sumSq float64
+	for _, m := range g.members {
+		k := float64(count(m))
+		mu, sd := float64(mean(m)), float64(stdDev(m))
+		n += k
+		sum += k * mu
+		sumSq += k * (sd*sd + mu*mu)
+	}
+	if n == 0 {
+		return 0
+	}
+	mu := sum / n
+	return time.Duration(math.Round(math.Sqrt(math.Max(sumSq/n-mu*mu, 0))))
+}
+
+// GetPercentileRTT returns the q-quantile over all members' RTTs
+func (g *groupMetrics) GetPercentileRTT(q float64) time.Duration {
+	var s rttSketch
+	for _, m := range g.members {
+		if mi, ok := m.(*metrics); ok {
+			s.Merge(&mi.sketch)
+		}
+	}
+	return s.Quantile(q)
+}
+
+func (g *groupMetrics) GetLastSuccTime() time.Time {
+	var last time.Time
+	for _, m := range g.members {
+		if t := m.GetLastSuccTime(); t.After(last) {
+			last = t
+		}
+	}
+	return last
+}
+
+func (g *groupMetrics) GetLastFailTime() time.Time {
+	var last time.Time
+	for _, m := range g.members {
+		if t := m.GetLastFailTime(); t.After(last) {
+			last = t
+		}
+	}
+	return last
+}
+
+// GetLastFailDetail returns the failure reason of the most recently failed member
+func (g *groupMetrics) GetLastFailDetail() string {
+	var last time.Time
+	detail := ""
+	for _, m := range g.members {
+		if t := m.GetLastFailTime(); t.After(last) {
+			last = t
+			detail = m.GetLastFailDetail()
+		}
+	}
+	return detail
+}
+
+func (g *groupMetrics) GetDuplicates() int {
+	return g.sum(Metrics.GetDuplicates)
+}
+
+func (g *groupMetrics) GetReordered() int {
+	return g.sum(Metrics.GetReordered)
+}
+
+func (g *groupMetrics) GetLate() int {
+	return g.sum(Metrics.GetLate)
+}
+
+// GetIncidents merges the members' incidents, newest first
+func (g *groupMetrics) GetIncidents() []Incident {
+	lists := make([][]Incident, len(g.members))
+	for i, m := range g.members {
+		lists[i] = m.GetIncidents()
+	}
+	return mergeIncidents(lists...)
+}
+
+func (g *groupMetrics) GetOutages() int {
+	return g.sum(Metrics.GetOutages)
+}
+
+func (g *groupMetrics) GetDowntime() time.Duration {
+	var downtime time.Duration
+	for _, m := range g.members {
+		downtime += m.GetDowntime()
+	}
+	return downtime
+}
+
+// GetMTTR returns the mean duration of the members' kept, closed incidents
+func (g *groupMetrics) GetMTTR() time.Duration {
+	var total time.Duration
+	closed := 0
+	for _, i := range g.GetIncidents() {
+		if !i.Ongoing() {
+			total += i.Duration()
+			closed++
+		}
+	}
+	if closed == 0 {
+		return 0
+	}
+	return total / time.Duration(closed)
+}
+
+// GetAvailability returns the members' average availability
+func (g *groupMetrics) GetAvailability() float64 {
+	if len(g.members) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, m := range g.members {
+		sum += m.GetAvailability()
+	}
+	return sum / float64(len(g.members))
+}
+
+// GetRecentHistory merges the members' histories (newest first)
+func (g *groupMetrics) GetRecentHistory(n int) []HistoryEntry {
+	var entries []HistoryEntry
+	for _, m := range g.members {
+		entries = append(entries, m.GetRecentHistory(n)...)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Timestamp.After(entries[j].Timestamp)
+	})
+	if len(entries) > n {
+		entries = entries[:n]
+	}
+	return entries
+}
+
+// GetRollups combines the members' rollups period by period
+func (g *groupMetrics) GetRollups(resolution time.Duration, n int) []Rollup {
+	series := make([][]Rollup, len(g.members))
+	for i, m := range g.members {
+		series[i] = m.GetRollups(resolution, n)
+	}
+	return mergeRollups(series...)
+}
+
+// GetConsecutiveFailures returns the streak shared by all members, i.e. how
+// long the whole group has been failing
+func (g *groupMetrics) GetConsecutiveFailures() int {
+	return g.minimum(Metrics.GetConsecutiveFailures)
+}
+
+// GetConsecutiveSuccesses returns the streak shared by all members
+func (g *groupMetrics) GetConsecutiveSuccesses() int {
+	return g.minimum(Metrics.GetConsecutiveSuccesses)
+}
+
+func (g *groupMetrics) GetSuccessRateInPeriod(duration time.Duration) float64 {
+	if len(g.members) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, m := range g.members {
+		sum += m.GetSuccessRateInPeriod(duration)
+	}
+	return sum / float64(len(g.members))
+}
+
+func (g *groupMetrics) GetSuccessfulInPeriod(duration time.Duration) int {
+	successful := 0
+	for _, m := range g.members {
+		successful += m.GetSuccessfulInPeriod(duration)
+	}
+	return successful
+}
+
+func (g *groupMetrics) GetFailedInPeriod(duration time.Duration) int {
+	failed := 0
+	for _, m := range g.members {
+		failed += m.GetFailedInPeriod(duration)
+	}
+	return failed
+}
+
+func (g *groupMetrics) GetLossInPeriod(duration time.Duration) float64 {
+	successful, failed := g.GetSuccessfulInPeriod(duration), g.GetFailedInPeriod(duration)
+	if successful+failed == 0 {
+		return 0
+	}
+	return float64(failed) / float64(successful+failed) * 100
+}
+
+func (g *groupMetrics) GetAverageRTTInPeriod(duration time.Duration) time.Duration {
+	var total time.Duration
+	successful := 0
+	for _, m := range g.members {
+		n := m.GetSuccessfulInPeriod(duration)
+		total += m.GetAverageRTTInPeriod(duration) * time.Duration(n)
+		successful += n
+	}
+	if successful == 0 {
+		return 0
+	}
+	return total / time.Duration(successful)
+}
+
+func (g *groupMetrics) GetMinimumRTTInPeriod(duration time.Duration) time.Duration {
+	var minimum time.Duration
+	for _, m := range g.members {
+		if rtt := m.GetMinimumRTTInPeriod(duration); rtt != 0 && (minimum == 0 || rtt < minimum) {
+			minimum = rtt
+		}
+	}
+	return minimum
+}
+
+func (g *groupMetrics) GetMaximumRTTInPeriod(duration time.Duration) time.Duration {
+	var maximum time.Duration
+	for _, m := range g.members {
+		if rtt := m.GetMaximumRTTInPeriod(duration); rtt > maximum {
+			maximum = rtt
+		}
+	}
+	return maximum
+}
+
+func (g *groupMetrics) GetStdDevInPeriod(duration time.Duration) time.Duration {
+	return g.pooledStdDev(
+		func(m Metrics) int { return m.GetSuccessfulInPeriod(duration) },
+		func(m Metrics) time.Duration { return m.GetAverageRTTInPeriod(duration) },
+		func(m Metrics) time.Duration { return m.GetStdDevInPeriod(duration) },
+	)
+}
+
+func (g *groupMetrics) minimum(f func(Metrics) int) int {
+	res := 0
+	for i, m := range g.members {
+		if v := f(m); i == 0 || v < res {
+			res = v
+		}
+	}
+	return res
+}
+
+func (g *groupMetrics) sum(f func(Metrics) int) int {
+	res := 0
+	for _, m := range g.members {
+		res += f(m)
+	}
+	return res
+}
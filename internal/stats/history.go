@@ -13,6 +13,15 @@ type HistoryEntry struct {
 	Success   bool          `json:"success"`
 	Error     string        `json:"error,omitempty"`
 	Details   *prober.ProbeDetails `json:"details,omitempty"`
+	Event     string        `json:"event,omitempty"` // Non-probe event (e.g. address change), neither success nor failure
+	Duplicate bool          `json:"duplicate,omitempty"` // Event for an extra reply to an answered probe
+	Late      bool          `json:"late,omitempty"`      // Event for a reply to a probe that already timed out
+	Reordered bool          `json:"reordered,omitempty"` // Successful reply that arrived after a later probe's reply
+}
+
+// IsEvent reports whether the entry records an event rather than a probe result
+func (e HistoryEntry) IsEvent() bool {
+	return e.Event != ""
 }
 
 // Structure to manage history for each target (ring buffer)
@@ -92,6 +101,9 @@ func (th *TargetHistory) GetConsecutiveFailures() int {
 	for i := 0; i < th.count; i++ {
 		pos := (th.index - 1 - i + th.size) % th.size
 		entry := th.entries[pos]
+		if entry.IsEvent() {
+			continue
+		}
 		if !entry.Success {
 			count++
 		} else {
@@ -112,6 +124,9 @@ func (th *TargetHistory) GetConsecutiveSuccesses() int {
 	for i := 0; i < th.count; i++ {
 		pos := (th.index - 1 - i + th.size) % th.size
 		entry := th.entries[pos]
+		if entry.IsEvent() {
+			continue
+		}
 		if entry.Success {
 			count++
 		} else {
@@ -131,18 +146,21 @@ func (th *TargetHistory) GetSuccessRateInPeriod(duration time.Duration) float64
 	since := time.Now().Add(-duration)
 	entries := th.GetEntriesSince(since)
 	
-	if len(entries) == 0 {
-		return 0.0
-	}
-
-	successCount := 0
+	successCount, total := 0, 0
 	for _, entry := range entries {
+		if entry.IsEvent() {
+			continue
+		}
+		total++
 		if entry.Success {
 			successCount++
 		}
 	}
+	if total == 0 {
+		return 0.0
+	}
 
-	return float64(successCount) / float64(len(entries)) * 100.0
+	return float64(successCount) / float64(total) * 100.0
 }
 
 // Clear clears the history
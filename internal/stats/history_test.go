@@ -172,3 +172,158 @@ func TestSuccessRateInPeriod(t *testing.T) {
 		t.Errorf("Expected 0%% success rate for recent period, got %f%%", recentRate)
 	}
 }
+
+func TestFailedWithDetails(t *testing.T) {
+	mm := metricsManager{
+		metrics:     make(map[string]*metrics),
+		historySize: DefaultHistorySize,
+	}
+	host := "192.0.2.1"
+
+	details := &prober.ProbeDetails{
+		ProbeType: "icmpv4",
+		ICMP: &prober.ICMPDetails{
+			ICMPType: 3,
+			ICMPCode: 1,
+			From:     "198.51.100.1",
+		},
+	}
+	mm.FailedWithDetails(host, time.Now(), "host unreachable (type=3 code=1) from 198.51.100.1", details)
+
+	metrics := mm.GetMetrics(host)
+	if metrics.GetFailed() != 1 {
+		t.Errorf("Expected failed to be 1, got %d", metrics.GetFailed())
+	}
+	history := metrics.GetRecentHistory(1)
+	if len(history) != 1 {
+		t.Fatalf("Expected 1 history entry, got %d", len(history))
+	}
+	if history[0].Success {
+		t.Errorf("Expected history entry to be failure")
+	}
+	if history[0].Details == nil || history[0].Details.ICMP == nil || history[0].Details.ICMP.From != "198.51.100.1" {
+		t.Errorf("Expected history entry to keep ICMP error details")
+	}
+}
+
+func TestProberErrors(t *testing.T) {
+	mm := NewMetricsManager()
+	events := make(chan *prober.Event, 3)
+	now := time.Now()
+	events <- &prober.Event{Key: "icmpv6", Result: prober.ERROR, Message: "read failed", SentTime: now}
+	events <- &prober.Event{Key: "icmpv4", Result: prober.ERROR, Message: "read failed", SentTime: now}
+	events <- &prober.Event{Key: "icmpv6", Result: prober.RECOVERED, SentTime: now}
+	close(events)
+	mm.Subscribe(events)
+
+	deadline := time.Now().Add(time.Second)
+	var errs []ProberError
+	for time.Now().Before(deadline) {
+		errs = mm.GetProberErrors()
+		if len(errs) == 1 && errs[0].Prober == "icmpv4" {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if len(errs) != 1 || errs[0].Prober != "icmpv4" || errs[0].Message != "read failed" {
+		t.Fatalf("Expected only icmpv4 error to remain, got %+v", errs)
+	}
+
+	// Prober errors must not create host rows
+	if len(mm.SortBy(Host, true)) != 0 {
+		t.Errorf("Expected no metrics for prober-level events")
+	}
+}
+
+func TestProberNotices(t *testing.T) {
+	mm := NewMetricsManager().(*metricsManager)
+	now := time.Now()
+	mm.Record(&prober.Event{Key: "tcp", Result: prober.NOTICE, Message: "SYN probing unavailable, using connect()", SentTime: now})
+
+	if errs := mm.GetProberErrors(); len(errs) != 0 {
+		t.Errorf("Expected a notice not to be a prober error, got %+v", errs)
+	}
+	notices := mm.GetProberNotices()
+	if len(notices) != 1 || notices[0].Prober != "tcp" || !notices[0].Time.Equal(now) {
+		t.Errorf("Expected the tcp notice, got %+v", notices)
+	}
+	if len(mm.SortBy(Host, true)) != 0 {
+		t.Errorf("Expected no metrics for prober-level events")
+	}
+}
+
+func TestResolvedEvent(t *testing.T) {
+	mm := metricsManager{
+		metrics:     make(map[string]*metrics),
+		historySize: DefaultHistorySize,
+	}
+	host := "192.0.2.1"
+	mm.autoRegister(host, "example.com(192.0.2.1)", "", "")
+
+	mm.Failed(host, time.Now(), "timeout")
+	mm.Resolved(host, "example.com(192.0.2.10)", time.Now(), "example.com resolved to 192.0.2.10 (was 192.0.2.1)")
+	mm.Failed(host, time.Now(), "timeout")
+
+	metrics := mm.GetMetrics(host)
+	if metrics.GetName() != "example.com(192.0.2.10)" {
+		t.Errorf("Expected name to be updated, got %s", metrics.GetName())
+	}
+	if metrics.GetFailed() != 2 || metrics.GetSuccessful() != 0 {
+		t.Errorf("Expected events not to be counted, got failed=%d successful=%d", metrics.GetFailed(), metrics.GetSuccessful())
+	}
+	history := metrics.GetRecentHistory(3)
+	if len(history) != 3 || !history[1].IsEvent() {
+		t.Fatalf("Expected resolve event in history, got %+v", history)
+	}
+	if got := metrics.GetConsecutiveFailures(); got != 2 {
+		t.Errorf("Expected 2 consecutive failures across the event, got %d", got)
+	}
+	if got := metrics.GetSuccessRateInPeriod(time.Minute); got != 0 {
+		t.Errorf("Expected success rate 0, got %.1f", got)
+	}
+}
+
+func TestReplyAnomalies(t *testing.T) {
+	mm := metricsManager{
+		metrics:     make(map[string]*metrics),
+		historySize: DefaultHistorySize,
+	}
+	host := "192.0.2.1"
+	mm.autoRegister(host, host, "", "")
+
+	events := make(chan *prober.Event, 10)
+	sent := time.Now()
+	events <- &prober.Event{Key: host, Result: prober.SUCCESS, SentTime: sent, Rtt: time.Millisecond}
+	events <- &prober.Event{Key: host, Result: prober.DUPLICATE, SentTime: sent, Rtt: 2 * time.Millisecond, Message: "duplicate reply seq=2"}
+	events <- &prober.Event{Key: host, Result: prober.SUCCESS, SentTime: sent, Rtt: time.Millisecond, Reordered: true}
+	events <- &prober.Event{Key: host, Result: prober.TIMEOUT, SentTime: sent, Message: "timeout"}
+	events <- &prober.Event{Key: host, Result: prober.LATE, SentTime: sent, Rtt: 2 * time.Second, Message: "late reply seq=3"}
+	close(events)
+	mm.Subscribe(events)
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		mm.mu.Lock()
+		late := mm.metrics[host].Late
+		mm.mu.Unlock()
+		if late == 1 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	m := mm.GetMetrics(host)
+
+	if m.GetDuplicates() != 1 || m.GetReordered() != 1 || m.GetLate() != 1 {
+		t.Errorf("Expected 1 duplicate, reordered and late reply, got %d/%d/%d", m.GetDuplicates(), m.GetReordered(), m.GetLate())
+	}
+	if m.GetSuccessful() != 2 || m.GetFailed() != 1 {
+		t.Errorf("Expected anomalies not to change probe counts, got successful=%d failed=%d", m.GetSuccessful(), m.GetFailed())
+	}
+	history := m.GetRecentHistory(5)
+	if len(history) != 5 || !history[0].Late || !history[0].IsEvent() || !history[2].Reordered || !history[3].Duplicate {
+		t.Errorf("Unexpected history flags: %+v", history)
+	}
+	if got := m.GetConsecutiveFailures(); got != 1 {
+		t.Errorf("Expected late reply not to end the failure streak, got %d", got)
+	}
+}
@@ -8,7 +8,10 @@ import (
 
 // Metrics provides basic statistics for display and sorting
 type Metrics interface {
+	GetKey() string // Stable identifier; the name may change when the target is re-resolved
 	GetName() string
+	GetGroup() string
+	GetFamily() string
 	GetTotal() int
 	GetSuccessful() int
 	GetFailed() int
@@ -17,14 +20,37 @@ type Metrics interface {
 	GetAverageRTT() time.Duration
 	GetMinimumRTT() time.Duration
 	GetMaximumRTT() time.Duration
+	GetJitter() time.Duration
+	GetStdDev() time.Duration
+	GetPercentileRTT(q float64) time.Duration
 	GetLastSuccTime() time.Time
 	GetLastFailTime() time.Time
 	GetLastFailDetail() string
+	GetDuplicates() int
+	GetReordered() int
+	GetLate() int
+
+	// Outages of consecutive failures
+	GetIncidents() []Incident
+	GetOutages() int
+	GetDowntime() time.Duration
+	GetMTTR() time.Duration
+	GetAvailability() float64
 
 	GetRecentHistory(n int) []HistoryEntry
+	GetRollups(resolution time.Duration, n int) []Rollup
 	GetConsecutiveFailures() int
 	GetConsecutiveSuccesses() int
 	GetSuccessRateInPeriod(duration time.Duration) float64
+
+	// Statistics over the last duration, up to MaxStatsWindow
+	GetSuccessfulInPeriod(duration time.Duration) int
+	GetFailedInPeriod(duration time.Duration) int
+	GetLossInPeriod(duration time.Duration) float64
+	GetAverageRTTInPeriod(duration time.Duration) time.Duration
+	GetMinimumRTTInPeriod(duration time.Duration) time.Duration
+	GetMaximumRTTInPeriod(duration time.Duration) time.Duration
+	GetStdDevInPeriod(duration time.Duration) time.Duration
 }
 
 // MetricsProvider provides external API for metrics access
@@ -35,12 +61,26 @@ type MetricsProvider interface {
 // MetricsSystemManager provides system-level operations
 type MetricsSystemManager interface {
 	ResetAllMetrics()
+	SetOutageThresholds(failures, recoveries int)
+}
+
+// ProberStatusProvider exposes prober-level errors (e.g. socket failures) and notices
+type ProberStatusProvider interface {
+	GetProberErrors() []ProberError
+	GetProberNotices() []ProberError
+}
+
+// StatePersister saves metrics to a file and restores them after a restart
+type StatePersister interface {
+	SaveState(path string) error
+	LoadState(path string) error
 }
 
 // MetricsEventRecorder handles internal event recording
 type MetricsEventRecorder interface {
 	Register(target, name string)
 	Subscribe(<-chan *prober.Event)
+	Record(*prober.Event)
 }
 
 // MetricsManager provides comprehensive metrics management (for backward compatibility)
@@ -48,4 +88,6 @@ type MetricsManager interface {
 	MetricsProvider
 	MetricsSystemManager
 	MetricsEventRecorder
+	ProberStatusProvider
+	StatePersister
 }
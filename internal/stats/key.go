@@ -12,6 +12,12 @@ const (
 	Avg
 	Best
 	Worst
+	Jitter
+	StdDev
+	P50
+	P90
+	P95
+	P99
 	LastSuccTime
 	LastFailTime
 )
@@ -36,6 +42,18 @@ func (s Key) String() string {
 		return "Best"
 	case Worst:
 		return "Worst"
+	case Jitter:
+		return "Jitter"
+	case StdDev:
+		return "StdDev"
+	case P50:
+		return "P50"
+	case P90:
+		return "P90"
+	case P95:
+		return "P95"
+	case P99:
+		return "P99"
 	case LastSuccTime:
 		return "Last Succ"
 	case LastFailTime:
@@ -46,7 +64,7 @@ func (s Key) String() string {
 }
 
 func Keys() []Key {
-	return []Key{Host, Sent, Success, Fail, Loss, Last, Avg, Best, Worst, LastSuccTime, LastFailTime}
+	return []Key{Host, Sent, Success, Fail, Loss, Last, Avg, Best, Worst, Jitter, StdDev, P50, P90, P95, P99, LastSuccTime, LastFailTime}
 }
 
 func KeyStrings() (res []string) {
@@ -55,3 +73,25 @@ func KeyStrings() (res []string) {
 	}
 	return
 }
+
+// Quantile returns the quantile reported by a percentile key (e.g. 0.95 for P95),
+// or 0 for other keys
+func (s Key) Quantile() float64 {
+	switch s {
+	case P50:
+		return 0.50
+	case P90:
+		return 0.90
+	case P95:
+		return 0.95
+	case P99:
+		return 0.99
+	}
+	return 0
+}
+
+// Spread reports whether the key is one of the RTT spread statistics: Jitter,
+// StdDev and the percentiles
+func (s Key) Spread() bool {
+	return s >= Jitter && s <= P99
+}
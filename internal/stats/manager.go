@@ -13,9 +13,21 @@ const (
 )
 
 type metricsManager struct {
-	metrics     map[string]*metrics
-	historySize int // Number of history entries to keep
-	mu          sync.Mutex
+	metrics          map[string]*metrics
+	historySize      int // Number of history entries to keep
+	outageFailures   int // Consecutive failures that open an incident (0 for the default)
+	outageRecoveries int // Consecutive successes that close it (0 for the default)
+	proberErrors     map[string]ProberError
+	proberNotices    map[string]ProberError
+	restored         map[string]*targetState // Loaded state of targets not registered yet
+	mu               sync.Mutex
+}
+
+// ProberError is the latest unrecovered error, or the latest notice, reported by a prober
+type ProberError struct {
+	Prober  string
+	Message string
+	Time    time.Time
 }
 
 // Create a new MetricsManager
@@ -36,9 +48,36 @@ func (mm *metricsManager) Register(target, name string) {
 	if ok && v.Name != target {
 		return
 	}
-	mm.metrics[target] = &metrics{
+	mm.metrics[target] = mm.newMetrics(target, name, "", "")
+}
+
+// newMetrics creates metrics with the manager's history size and outage
+// thresholds, restoring any loaded state of key
+func (mm *metricsManager) newMetrics(key, name, group, family string) *metrics {
+	m := &metrics{
+		Key:     key,
 		Name:    name,
+		Group:   group,
+		Family:  family,
 		history: NewTargetHistory(mm.historySize),
+		outage: outageTracker{
+			failuresToOpen:   mm.outageFailures,
+			successesToClose: mm.outageRecoveries,
+		},
+	}
+	mm.restore(key, m)
+	return m
+}
+
+// SetOutageThresholds sets how many consecutive failures open an incident and
+// how many consecutive successes close it, for current and future targets
+func (mm *metricsManager) SetOutageThresholds(failures, recoveries int) {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	mm.outageFailures, mm.outageRecoveries = failures, recoveries
+	for _, m := range mm.metrics {
+		m.outage.failuresToOpen, m.outage.successesToClose = failures, recoveries
 	}
 }
 
@@ -49,10 +88,7 @@ func (mm *metricsManager) getMetrics(host string) *metrics {
 
 	m, ok := mm.metrics[host]
 	if !ok {
-		m = &metrics{
-			Name:    host,
-			history: NewTargetHistory(mm.historySize),
-		}
+		m = mm.newMetrics(host, host, "", "")
 		// Register the new metrics for the host
 		mm.metrics[host] = m
 	}
@@ -79,16 +115,25 @@ func (mm *metricsManager) Success(host string, rtt time.Duration, sentTime time.
 
 // Register success for host with detailed information
 func (mm *metricsManager) SuccessWithDetails(host string, rtt time.Duration, sentTime time.Time, details *prober.ProbeDetails) {
+	mm.success(host, rtt, sentTime, details, false)
+}
+
+// success records a reply; reordered marks a reply that overtook a later probe's reply
+func (mm *metricsManager) success(host string, rtt time.Duration, sentTime time.Time, details *prober.ProbeDetails, reordered bool) {
 	m := mm.getMetrics(host)
 
 	mm.mu.Lock()
 	m.Success(rtt, sentTime)
+	if reordered {
+		m.Reordered++
+	}
 	if m.history != nil {
 		m.history.AddEntry(HistoryEntry{
 			Timestamp: sentTime,
 			RTT:       rtt,
 			Success:   true,
 			Details:   details,
+			Reordered: reordered,
 		})
 	}
 	mm.mu.Unlock()
@@ -96,6 +141,11 @@ func (mm *metricsManager) SuccessWithDetails(host string, rtt time.Duration, sen
 
 // Register failure for host
 func (mm *metricsManager) Failed(host string, sentTime time.Time, msg string) {
+	mm.FailedWithDetails(host, sentTime, msg, nil)
+}
+
+// Register failure for host with detailed information (e.g. ICMP error source)
+func (mm *metricsManager) FailedWithDetails(host string, sentTime time.Time, msg string, details *prober.ProbeDetails) {
 	m := mm.getMetrics(host)
 
 	mm.mu.Lock()
@@ -106,6 +156,61 @@ func (mm *metricsManager) Failed(host string, sentTime time.Time, msg string) {
 			RTT:       0,
 			Success:   false,
 			Error:     msg,
+			Details:   details,
+		})
+	}
+	mm.mu.Unlock()
+}
+
+// Resolved records a target address change. The display name is updated and
+// the change is kept in history without affecting probe counters.
+func (mm *metricsManager) Resolved(host, name string, t time.Time, msg string) {
+	m := mm.getMetrics(host)
+
+	mm.mu.Lock()
+	if name != "" {
+		m.Name = name
+	}
+	if m.history != nil {
+		m.history.AddEntry(HistoryEntry{
+			Timestamp: t,
+			Event:     msg,
+		})
+	}
+	mm.mu.Unlock()
+}
+
+// Duplicate records an extra reply to a probe that was already answered.
+// It is kept in history without affecting probe counters.
+func (mm *metricsManager) Duplicate(host string, rtt time.Duration, sentTime time.Time, msg string) {
+	m := mm.getMetrics(host)
+
+	mm.mu.Lock()
+	m.Duplicates++
+	if m.history != nil {
+		m.history.AddEntry(HistoryEntry{
+			Timestamp: sentTime.Add(rtt), // Arrival time, keeping history in order
+			RTT:       rtt,
+			Event:     msg,
+			Duplicate: true,
+		})
+	}
+	mm.mu.Unlock()
+}
+
+// Late records a reply to a probe that was already counted as a timeout.
+// The timeout stands; the reply is kept in history without affecting probe counters.
+func (mm *metricsManager) Late(host string, rtt time.Duration, sentTime time.Time, msg string) {
+	m := mm.getMetrics(host)
+
+	mm.mu.Lock()
+	m.Late++
+	if m.history != nil {
+		m.history.AddEntry(HistoryEntry{
+			Timestamp: sentTime.Add(rtt), // Arrival time, keeping history in order
+			RTT:       rtt,
+			Event:     msg,
+			Late:      true,
 		})
 	}
 	mm.mu.Unlock()
@@ -122,33 +227,102 @@ func (mm *metricsManager) Sent(host string) {
 func (mm *metricsManager) Subscribe(res <-chan *prober.Event) {
 	go func() {
 		for r := range res {
-			switch r.Result {
-			case prober.REGISTER:
-				mm.autoRegister(r.Key, r.DisplayName)
-			case prober.SENT:
-				mm.Sent(r.Key)
-			case prober.SUCCESS:
-				mm.SuccessWithDetails(r.Key, r.Rtt, r.SentTime, r.Details)
-			case prober.TIMEOUT:
-				mm.Failed(r.Key, r.SentTime, r.Message)
-			case prober.FAILED:
-				mm.Failed(r.Key, r.SentTime, r.Message)
-			}
+			mm.Record(r)
 		}
 	}()
 }
 
+// Record applies a single probe event to the metrics
+func (mm *metricsManager) Record(r *prober.Event) {
+	switch r.Result {
+	case prober.REGISTER:
+		mm.autoRegister(r.Key, r.DisplayName, r.Group, r.Family)
+	case prober.SENT:
+		mm.Sent(r.Key)
+	case prober.SUCCESS:
+		mm.success(r.Key, r.Rtt, r.SentTime, r.Details, r.Reordered)
+	case prober.TIMEOUT:
+		mm.Failed(r.Key, r.SentTime, r.Message)
+	case prober.FAILED:
+		mm.FailedWithDetails(r.Key, r.SentTime, r.Message, r.Details)
+	case prober.ERROR:
+		mm.setProberError(r.Key, r.Message, r.SentTime)
+	case prober.RECOVERED:
+		mm.clearProberError(r.Key)
+	case prober.RESOLVED:
+		mm.Resolved(r.Key, r.DisplayName, r.SentTime, r.Message)
+	case prober.DUPLICATE:
+		mm.Duplicate(r.Key, r.Rtt, r.SentTime, r.Message)
+	case prober.LATE:
+		mm.Late(r.Key, r.Rtt, r.SentTime, r.Message)
+	case prober.NOTICE:
+		mm.setProberNotice(r.Key, r.Message, r.SentTime)
+	}
+}
+
 // autoRegister automatically registers target if not already registered
-func (mm *metricsManager) autoRegister(key, displayName string) {
+func (mm *metricsManager) autoRegister(key, displayName, group, family string) {
 	mm.mu.Lock()
 	defer mm.mu.Unlock()
 
 	if _, exists := mm.metrics[key]; !exists {
-		mm.metrics[key] = &metrics{
-			Name:    displayName,
-			history: NewTargetHistory(mm.historySize),
-		}
+		mm.metrics[key] = mm.newMetrics(key, displayName, group, family)
+	}
+}
+
+func (mm *metricsManager) setProberError(name, msg string, t time.Time) {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	if mm.proberErrors == nil {
+		mm.proberErrors = make(map[string]ProberError)
+	}
+	mm.proberErrors[name] = ProberError{Prober: name, Message: msg, Time: t}
+}
+
+func (mm *metricsManager) clearProberError(name string) {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	delete(mm.proberErrors, name)
+}
+
+func (mm *metricsManager) setProberNotice(name, msg string, t time.Time) {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	if mm.proberNotices == nil {
+		mm.proberNotices = make(map[string]ProberError)
+	}
+	mm.proberNotices[name] = ProberError{Prober: name, Message: msg, Time: t}
+}
+
+// GetProberErrors returns unrecovered prober errors sorted by prober name
+func (mm *metricsManager) GetProberErrors() []ProberError {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	return sortedProberErrors(mm.proberErrors)
+}
+
+// GetProberNotices returns the latest notice of each prober, such as a
+// fallback that keeps probing working, sorted by prober name
+func (mm *metricsManager) GetProberNotices() []ProberError {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	return sortedProberErrors(mm.proberNotices)
+}
+
+func sortedProberErrors(m map[string]ProberError) []ProberError {
+	res := make([]ProberError, 0, len(m))
+	for _, e := range m {
+		res = append(res, e)
 	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Prober < res[j].Prober
+	})
+	return res
 }
 
 // SortBy sorts metrics by specified key and returns Metrics slice
@@ -159,7 +333,11 @@ func (mm *metricsManager) SortBy(k Key, ascending bool) []Metrics {
 		res = append(res, m)
 	}
 	mm.mu.Unlock()
+	return SortMetrics(res, k, ascending)
+}
 
+// SortMetrics sorts ms in place by k, with ties in host name order, and returns it
+func SortMetrics(res []Metrics, k Key, ascending bool) []Metrics {
 	if k != Host {
 		sort.SliceStable(res, func(i, j int) bool {
 			return res[i].GetName() < res[j].GetName()
@@ -188,6 +366,12 @@ func (mm *metricsManager) SortBy(k Key, ascending bool) []Metrics {
 			result = rejectLessAscending(mi.GetMinimumRTT(), mj.GetMinimumRTT())
 		case Worst:
 			result = rejectLessAscending(mi.GetMaximumRTT(), mj.GetMaximumRTT())
+		case Jitter:
+			result = rejectLessAscending(mi.GetJitter(), mj.GetJitter())
+		case StdDev:
+			result = rejectLessAscending(mi.GetStdDev(), mj.GetStdDev())
+		case P50, P90, P95, P99:
+			result = rejectLessAscending(mi.GetPercentileRTT(k.Quantile()), mj.GetPercentileRTT(k.Quantile()))
 		case LastSuccTime:
 			result = mi.GetLastSuccTime().Before(mj.GetLastSuccTime())
 		case LastFailTime:
@@ -1,11 +1,13 @@
 package stats
 
 import (
+	"math"
 	"time"
 )
 
 func NewMetrics(name string, historySize int) Metrics {
 	return &metrics{
+		Key:     name,
 		Name:    name,
 		history: NewTargetHistory(historySize),
 	}
@@ -13,6 +15,7 @@ func NewMetrics(name string, historySize int) Metrics {
 
 func NewMetricsForTest(name string, historySize, total, success, failed int, loss float64, totalRTT, averageRTT, minimumRTT, maximumRTT, lastRTT time.Duration, lastSuccTime, lastFailTime time.Time, lastFailDetail string) Metrics {
 	return &metrics{
+		Key:            name,
 		Name:           name,
 		Total:          total,
 		Successful:     success,
@@ -31,7 +34,10 @@ func NewMetricsForTest(name string, historySize, total, success, failed int, los
 }
 
 type metrics struct {
+	Key            string
 	Name           string
+	Group          string // Hostname this target was expanded from, if any
+	Family         string // "v4" or "v6" for dual-stack comparison targets
 	Total          int
 	Successful     int
 	Failed         int
@@ -44,11 +50,26 @@ type metrics struct {
 	LastFailTime   time.Time
 	LastSuccTime   time.Time
 	LastFailDetail string
+	Duplicates     int            // Extra replies to already answered probes
+	Reordered      int            // Replies that arrived after a later probe's reply
+	Late           int            // Replies that arrived after the probe timed out
+	Jitter         time.Duration  // RFC 3550 mean deviation of successive RTTs
+	StdDev         time.Duration  // Standard deviation of all RTTs
 	history        *TargetHistory // 履歴情報
+
+	jitter  float64       // Unrounded Jitter, in nanoseconds
+	rttMean float64       // Running mean for StdDev (Welford), in nanoseconds
+	rttM2   float64       // Running sum of squared deviations for StdDev
+	sketch  rttSketch     // Streaming RTT distribution for percentiles
+	clock   time.Time     // Newest probe time, which the window and rollups are kept at
+	window  rollingWindow // Recent results for the *InPeriod statistics
+	outage  outageTracker // Incidents of consecutive failures
+	rollups rollups       // Per-minute and per-hour results beyond the history
 }
 
 func (m *metrics) Success(rtt time.Duration, sentTime time.Time) {
 	m.Successful++
+	m.rttSpread(rtt)
 	m.LastSuccTime = sentTime
 	m.LastRTT = rtt
 	m.TotalRTT += rtt
@@ -59,16 +80,59 @@ func (m *metrics) Success(rtt time.Duration, sentTime time.Time) {
 	if rtt > m.MaximumRTT {
 		m.MaximumRTT = rtt
 	}
+	m.tick(sentTime)
+	m.window.Success(sentTime, m.clock, rtt)
+	m.outage.Success(sentTime)
+	m.rollups.Success(sentTime, m.clock, rtt)
 	m.loss()
 }
 
+// rttSpread updates jitter, standard deviation and percentiles with a new RTT
+func (m *metrics) rttSpread(rtt time.Duration) {
+	x := float64(rtt)
+	if m.Successful > 1 {
+		// J(i) = J(i-1) + (|D(i-1,i)| - J(i-1))/16, with D the difference of successive RTTs
+		m.jitter += (math.Abs(x-float64(m.LastRTT)) - m.jitter) / 16
+		m.Jitter = time.Duration(math.Round(m.jitter))
+	}
+
+	delta := x - m.rttMean
+	m.rttMean += delta / float64(m.Successful)
+	m.rttM2 += delta * (x - m.rttMean)
+	m.StdDev = time.Duration(math.Round(math.Sqrt(m.rttM2 / float64(m.Successful))))
+
+	m.sketch.Add(rtt)
+}
+
 func (m *metrics) Fail(sentTime time.Time, msg string) {
 	m.Failed++
 	m.LastFailTime = sentTime
 	m.LastFailDetail = msg
+	m.tick(sentTime)
+	m.window.Fail(sentTime, m.clock)
+	m.outage.Fail(sentTime, msg)
+	m.rollups.Fail(sentTime, m.clock)
 	m.loss()
 }
 
+// tick advances the clock of the window and rollups to the probe time t. They
+// follow the probes rather than the wall clock, so that a replayed recording
+// fills them as the live run did.
+func (m *metrics) tick(t time.Time) {
+	if t.After(m.clock) {
+		m.clock = t
+	}
+}
+
+// now returns the time the window and rollups are read at: the newest probe
+// time, or the current time before the first probe
+func (m *metrics) now() time.Time {
+	if m.clock.IsZero() {
+		return time.Now()
+	}
+	return m.clock
+}
+
 func (m *metrics) loss() {
 	m.Loss = float64(m.Failed) / float64(m.Successful+m.Failed) * 100
 }
@@ -90,6 +154,19 @@ func (m *metrics) Reset() {
 	m.LastFailTime = time.Time{}
 	m.LastSuccTime = time.Time{}
 	m.LastFailDetail = ""
+	m.Duplicates = 0
+	m.Reordered = 0
+	m.Late = 0
+	m.Jitter = 0
+	m.StdDev = 0
+	m.jitter = 0
+	m.rttMean = 0
+	m.rttM2 = 0
+	m.sketch.Reset()
+	m.clock = time.Time{}
+	m.window.Reset()
+	m.outage.Reset()
+	m.rollups.Reset()
 	if m.history != nil {
 		m.history.Clear()
 	}
@@ -97,10 +174,22 @@ func (m *metrics) Reset() {
 
 // Implementation of MetricsReader interface
 
+func (m *metrics) GetKey() string {
+	return m.Key
+}
+
 func (m *metrics) GetName() string {
 	return m.Name
 }
 
+func (m *metrics) GetGroup() string {
+	return m.Group
+}
+
+func (m *metrics) GetFamily() string {
+	return m.Family
+}
+
 func (m *metrics) GetTotal() int {
 	return m.Total
 }
@@ -145,6 +234,57 @@ func (m *metrics) GetLastFailDetail() string {
 	return m.LastFailDetail
 }
 
+func (m *metrics) GetJitter() time.Duration {
+	return m.Jitter
+}
+
+func (m *metrics) GetStdDev() time.Duration {
+	return m.StdDev
+}
+
+// GetPercentileRTT returns the estimated q-quantile (e.g. 0.95) of all RTTs
+func (m *metrics) GetPercentileRTT(q float64) time.Duration {
+	return m.sketch.Quantile(q)
+}
+
+func (m *metrics) GetDuplicates() int {
+	return m.Duplicates
+}
+
+func (m *metrics) GetReordered() int {
+	return m.Reordered
+}
+
+func (m *metrics) GetLate() int {
+	return m.Late
+}
+
+// GetIncidents returns the recent outages, newest first
+func (m *metrics) GetIncidents() []Incident {
+	return m.outage.Incidents()
+}
+
+func (m *metrics) GetOutages() int {
+	return m.outage.count
+}
+
+func (m *metrics) GetDowntime() time.Duration {
+	return m.outage.Downtime()
+}
+
+func (m *metrics) GetMTTR() time.Duration {
+	return m.outage.MTTR()
+}
+
+func (m *metrics) GetAvailability() float64 {
+	return m.outage.Availability()
+}
+
+// GetRollups returns the last n per-minute or per-hour rollups, oldest first
+func (m *metrics) GetRollups(resolution time.Duration, n int) []Rollup {
+	return m.rollups.Get(m.now(), resolution, n)
+}
+
 func (m *metrics) GetRecentHistory(n int) []HistoryEntry {
 	if m.history == nil {
 		return []HistoryEntry{}
@@ -166,9 +306,50 @@ func (m *metrics) GetConsecutiveSuccesses() int {
 	return m.history.GetConsecutiveSuccesses()
 }
 
+// GetSuccessRateInPeriod covers every probe of the period up to MaxStatsWindow,
+// and falls back to the history for longer periods
 func (m *metrics) GetSuccessRateInPeriod(duration time.Duration) float64 {
+	if duration <= MaxStatsWindow {
+		s := m.periodStats(duration)
+		if s.successful+s.failed == 0 {
+			return 0.0
+		}
+		return 100 - s.loss()
+	}
 	if m.history == nil {
 		return 0.0
 	}
 	return m.history.GetSuccessRateInPeriod(duration)
 }
+
+func (m *metrics) periodStats(duration time.Duration) periodStats {
+	return m.window.Stats(m.now(), duration)
+}
+
+func (m *metrics) GetSuccessfulInPeriod(duration time.Duration) int {
+	return m.periodStats(duration).successful
+}
+
+func (m *metrics) GetFailedInPeriod(duration time.Duration) int {
+	return m.periodStats(duration).failed
+}
+
+func (m *metrics) GetLossInPeriod(duration time.Duration) float64 {
+	return m.periodStats(duration).loss()
+}
+
+func (m *metrics) GetAverageRTTInPeriod(duration time.Duration) time.Duration {
+	return m.periodStats(duration).averageRTT()
+}
+
+func (m *metrics) GetMinimumRTTInPeriod(duration time.Duration) time.Duration {
+	return m.periodStats(duration).minRTT
+}
+
+func (m *metrics) GetMaximumRTTInPeriod(duration time.Duration) time.Duration {
+	return m.periodStats(duration).maxRTT
+}
+
+func (m *metrics) GetStdDevInPeriod(duration time.Duration) time.Duration {
+	return m.periodStats(duration).stdDev()
+}
@@ -1,6 +1,8 @@
 package stats
 
 import (
+	"fmt"
+	"math"
 	"testing"
 	"time"
 )
@@ -49,3 +51,459 @@ func TestMetrics(t *testing.T) {
 		t.Errorf("Invalid loss calculation: Loss = %f", m.GetLoss())
 	}
 }
+
+func TestGroupMetrics(t *testing.T) {
+	now := time.Now()
+	a := &metrics{Name: "example.com(192.0.2.1)", Group: "example.com", history: NewTargetHistory(10)}
+	b := &metrics{Name: "example.com(192.0.2.2)", Group: "example.com", history: NewTargetHistory(10)}
+	other := &metrics{Name: "other", history: NewTargetHistory(10)}
+
+	a.Sent()
+	a.Success(10*time.Millisecond, now)
+	b.Sent()
+	b.Success(30*time.Millisecond, now.Add(time.Second))
+	b.Sent()
+	b.Fail(now.Add(2*time.Second), "timeout")
+
+	res := GroupMetrics([]Metrics{other, b, a})
+	if len(res) != 4 {
+		t.Fatalf("Expected 4 rows (other, aggregate, 2 members), got %d", len(res))
+	}
+	if res[0] != Metrics(other) || res[2] != Metrics(b) || res[3] != Metrics(a) {
+		t.Errorf("Expected sort order to be kept with members after the aggregate")
+	}
+
+	agg := res[1]
+	if agg.GetName() != "example.com [2 addrs]" || agg.GetGroup() != "" {
+		t.Errorf("Unexpected aggregate name/group: %q/%q", agg.GetName(), agg.GetGroup())
+	}
+	if agg.GetTotal() != 3 || agg.GetSuccessful() != 2 || agg.GetFailed() != 1 {
+		t.Errorf("Unexpected counts: total=%d successful=%d failed=%d", agg.GetTotal(), agg.GetSuccessful(), agg.GetFailed())
+	}
+	if agg.GetAverageRTT() != 20*time.Millisecond || agg.GetMinimumRTT() != 10*time.Millisecond || agg.GetMaximumRTT() != 30*time.Millisecond {
+		t.Errorf("Unexpected RTT: avg=%v min=%v max=%v", agg.GetAverageRTT(), agg.GetMinimumRTT(), agg.GetMaximumRTT())
+	}
+	if agg.GetLastRTT() != 30*time.Millisecond || agg.GetLastFailDetail() != "timeout" {
+		t.Errorf("Unexpected last values: rtt=%v detail=%q", agg.GetLastRTT(), agg.GetLastFailDetail())
+	}
+
+	// Ungrouped input is returned unchanged
+	if res := GroupMetrics([]Metrics{other}); len(res) != 1 || res[0] != Metrics(other) {
+		t.Errorf("Expected ungrouped metrics to pass through")
+	}
+}
+
+func TestComparisonMetrics(t *testing.T) {
+	now := time.Now()
+	v4 := &metrics{Name: "example.com(192.0.2.1)", Group: "dual@example.com", Family: "v4", history: NewTargetHistory(10)}
+	v6 := &metrics{Name: "example.com(2001:db8::1)", Group: "dual@example.com", Family: "v6", history: NewTargetHistory(10)}
+
+	v4.Sent()
+	v4.Success(10*time.Millisecond, now)
+	v6.Sent()
+	v6.Success(25*time.Millisecond, now)
+	v6.Sent()
+	v6.Fail(now, "timeout")
+
+	// IPv6 sorted first; the comparison keeps v4 before v6
+	res := GroupMetrics([]Metrics{v6, v4})
+	if len(res) != 3 || res[1] != Metrics(v4) || res[2] != Metrics(v6) {
+		t.Fatalf("Expected comparison row followed by v4 and v6, got %d rows", len(res))
+	}
+	c, ok := res[0].(Comparison)
+	if !ok {
+		t.Fatal("Expected aggregate row to be a Comparison")
+	}
+	if c.GetName() != "dual@example.com [v6-v4]" {
+		t.Errorf("Unexpected name: %s", c.GetName())
+	}
+	if c.GetAverageRTTDelta() != 15*time.Millisecond || c.GetLossDelta() != 50 {
+		t.Errorf("Unexpected deltas: avg=%v loss=%.1f", c.GetAverageRTTDelta(), c.GetLossDelta())
+	}
+
+	// Unmeasured sides give no RTT delta
+	v6.Reset()
+	if c.GetLastRTTDelta() != 0 {
+		t.Errorf("Expected no delta without IPv6 measurements, got %v", c.GetLastRTTDelta())
+	}
+}
+
+func TestComparisonMetricsKeys(t *testing.T) {
+	var ms []Metrics
+	for _, host := range []string{"a.example", "b.example"} {
+		group := "dual@" + host
+		ms = append(ms,
+			&metrics{Key: host + "/v4", Name: host + "(192.0.2.1)", Group: group, Family: "v4"},
+			&metrics{Key: host + "/v6", Name: host + "(2001:db8::1)", Group: group, Family: "v6"},
+		)
+	}
+
+	res := GroupMetrics(ms)
+	if len(res) != 6 {
+		t.Fatalf("Expected 2 comparison rows with 2 members each, got %d rows", len(res))
+	}
+	keys := make(map[string]bool)
+	for _, m := range res {
+		if _, ok := m.(Comparison); ok && m.GetKey() == "" {
+			t.Errorf("Expected a key for comparison row %s", m.GetName())
+		}
+		if keys[m.GetKey()] {
+			t.Errorf("Duplicate key %q for row %s", m.GetKey(), m.GetName())
+		}
+		keys[m.GetKey()] = true
+	}
+}
+
+func TestRTTSpread(t *testing.T) {
+	m := NewMetrics("", 10).(*metrics)
+	now := time.Now()
+	for _, rtt := range []time.Duration{10, 20, 10, 20} {
+		m.Sent()
+		m.Success(rtt*time.Millisecond, now)
+	}
+
+	// RFC 3550: J += (|D| - J)/16 with |D| = 10ms for every step after the first
+	jitter := 0.0
+	for i := 0; i < 3; i++ {
+		jitter += (float64(10*time.Millisecond) - jitter) / 16
+	}
+	if got, want := m.GetJitter(), time.Duration(jitter+0.5); got != want {
+		t.Errorf("GetJitter() = %v, want %v", got, want)
+	}
+	if got := m.GetStdDev(); got != 5*time.Millisecond {
+		t.Errorf("GetStdDev() = %v, want 5ms", got)
+	}
+
+	m.Reset()
+	if m.GetJitter() != 0 || m.GetStdDev() != 0 || m.GetPercentileRTT(0.5) != 0 {
+		t.Errorf("Expected spread to be reset, got jitter=%v stddev=%v p50=%v", m.GetJitter(), m.GetStdDev(), m.GetPercentileRTT(0.5))
+	}
+}
+
+func TestPercentileRTT(t *testing.T) {
+	m := NewMetrics("", 10).(*metrics)
+	now := time.Now()
+	// More samples than the history holds, in an order unrelated to their value
+	for i := 0; i < 1000; i++ {
+		m.Success(time.Duration((i*7919)%1000+1)*time.Millisecond, now)
+	}
+
+	tests := []struct {
+		key  Key
+		want time.Duration
+	}{
+		{P50, 500 * time.Millisecond},
+		{P90, 900 * time.Millisecond},
+		{P95, 950 * time.Millisecond},
+		{P99, 990 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		got := m.GetPercentileRTT(tt.key.Quantile())
+		if diff := math.Abs(float64(got-tt.want)) / float64(tt.want); diff > 0.02 {
+			t.Errorf("%s = %v, want %v within 2%%", tt.key, got, tt.want)
+		}
+	}
+	if got := NewMetrics("", 10).GetPercentileRTT(0.99); got != 0 {
+		t.Errorf("Expected 0 without samples, got %v", got)
+	}
+}
+
+func TestGroupSpread(t *testing.T) {
+	now := time.Now()
+	a := &metrics{Name: "a", Group: "example.com", history: NewTargetHistory(10)}
+	b := &metrics{Name: "b", Group: "example.com", history: NewTargetHistory(10)}
+	for i := 0; i < 50; i++ {
+		a.Success(10*time.Millisecond, now)
+		b.Success(30*time.Millisecond, now)
+	}
+
+	g := GroupMetrics([]Metrics{a, b})[0]
+	if got := g.GetStdDev(); got != 10*time.Millisecond {
+		t.Errorf("Group GetStdDev() = %v, want 10ms", got)
+	}
+	p25, p75 := g.GetPercentileRTT(0.25), g.GetPercentileRTT(0.75)
+	if math.Abs(float64(p25-10*time.Millisecond)) > float64(200*time.Microsecond) ||
+		math.Abs(float64(p75-30*time.Millisecond)) > float64(600*time.Microsecond) {
+		t.Errorf("Group percentiles p25=%v p75=%v, want about 10ms and 30ms", p25, p75)
+	}
+}
+
+func TestSortBySpread(t *testing.T) {
+	mm := metricsManager{
+		metrics:     make(map[string]*metrics),
+		historySize: DefaultHistorySize,
+	}
+	now := time.Now()
+	for host, rtts := range map[string][]time.Duration{
+		"steady": {10, 11, 10, 11},
+		"jumpy":  {5, 50, 5, 50},
+		"idle":   {},
+	} {
+		mm.Register(host, host)
+		for _, rtt := range rtts {
+			mm.Success(host, rtt*time.Millisecond, now, nil)
+		}
+	}
+
+	for _, k := range []Key{Jitter, StdDev, P99} {
+		res := mm.SortBy(k, true)
+		if res[0].GetName() != "steady" || res[1].GetName() != "jumpy" || res[2].GetName() != "idle" {
+			t.Errorf("SortBy(%s) = %s, %s, %s; want steady, jumpy, idle", k, res[0].GetName(), res[1].GetName(), res[2].GetName())
+		}
+	}
+}
+
+func TestMetricsInPeriod(t *testing.T) {
+	now := time.Now()
+	m := &metrics{Name: "a", history: NewTargetHistory(10)}
+	m.Success(10*time.Millisecond, now)
+	m.Success(30*time.Millisecond, now)
+	m.Fail(now.Add(-3*time.Minute), "timeout")
+	m.Success(50*time.Millisecond, now.Add(-10*time.Minute))
+	m.Fail(now.Add(-20*time.Minute), "timeout") // Older than MaxStatsWindow
+
+	for _, tc := range []struct {
+		period             time.Duration
+		successful, failed int
+		avg, best, worst   time.Duration
+	}{
+		{time.Minute, 2, 0, 20 * time.Millisecond, 10 * time.Millisecond, 30 * time.Millisecond},
+		{5 * time.Minute, 2, 1, 20 * time.Millisecond, 10 * time.Millisecond, 30 * time.Millisecond},
+		{15 * time.Minute, 3, 1, 30 * time.Millisecond, 10 * time.Millisecond, 50 * time.Millisecond},
+		{time.Hour, 3, 1, 30 * time.Millisecond, 10 * time.Millisecond, 50 * time.Millisecond},
+	} {
+		if got := m.GetSuccessfulInPeriod(tc.period); got != tc.successful {
+			t.Errorf("GetSuccessfulInPeriod(%v) = %d, want %d", tc.period, got, tc.successful)
+		}
+		if got := m.GetFailedInPeriod(tc.period); got != tc.failed {
+			t.Errorf("GetFailedInPeriod(%v) = %d, want %d", tc.period, got, tc.failed)
+		}
+		if got := m.GetAverageRTTInPeriod(tc.period); got != tc.avg {
+			t.Errorf("GetAverageRTTInPeriod(%v) = %v, want %v", tc.period, got, tc.avg)
+		}
+		if m.GetMinimumRTTInPeriod(tc.period) != tc.best || m.GetMaximumRTTInPeriod(tc.period) != tc.worst {
+			t.Errorf("Period %v: best=%v worst=%v, want %v and %v", tc.period, m.GetMinimumRTTInPeriod(tc.period), m.GetMaximumRTTInPeriod(tc.period), tc.best, tc.worst)
+		}
+	}
+	if got := m.GetLossInPeriod(5 * time.Minute); math.Abs(got-100.0/3) > 0.01 {
+		t.Errorf("GetLossInPeriod(5m) = %.2f, want 33.33", got)
+	}
+	if got := m.GetSuccessRateInPeriod(time.Minute); got != 100 {
+		t.Errorf("GetSuccessRateInPeriod(1m) = %.1f, want 100", got)
+	}
+	if got := m.GetStdDevInPeriod(time.Minute); got != 10*time.Millisecond {
+		t.Errorf("GetStdDevInPeriod(1m) = %v, want 10ms", got)
+	}
+
+	m.Reset()
+	if m.GetSuccessfulInPeriod(MaxStatsWindow) != 0 || m.GetFailedInPeriod(MaxStatsWindow) != 0 {
+		t.Error("Expected Reset() to clear the period statistics")
+	}
+}
+
+func TestInPeriod(t *testing.T) {
+	now := time.Now()
+	a := &metrics{Name: "a", Group: "example.com", history: NewTargetHistory(10)}
+	b := &metrics{Name: "b", Group: "example.com", history: NewTargetHistory(10)}
+	// a failed long ago but is fine now; b has just started failing
+	for i := 0; i < 4; i++ {
+		a.Fail(now.Add(-10*time.Minute), "timeout")
+		a.Success(10*time.Millisecond, now)
+		b.Success(30*time.Millisecond, now.Add(-10*time.Minute))
+	}
+	b.Fail(now, "timeout")
+
+	lifetime := SortMetrics([]Metrics{b, a}, Loss, false)
+	if lifetime[0].GetName() != "a" {
+		t.Errorf("Expected a to have the higher lifetime loss, got %s first", lifetime[0].GetName())
+	}
+
+	recent := SortMetrics(InPeriod(lifetime, time.Minute), Loss, false)
+	if recent[0].GetName() != "b" || recent[0].GetLoss() != 100 || recent[0].GetTotal() != 1 {
+		t.Errorf("Expected b first with 100%% loss over 1 probe, got %s with %.1f%% over %d", recent[0].GetName(), recent[0].GetLoss(), recent[0].GetTotal())
+	}
+	if recent[1].GetAverageRTT() != 10*time.Millisecond || recent[1].GetJitter() != 0 || recent[1].GetPercentileRTT(0.5) != 0 {
+		t.Errorf("Unexpected period view of a: avg=%v jitter=%v p50=%v", recent[1].GetAverageRTT(), recent[1].GetJitter(), recent[1].GetPercentileRTT(0.5))
+	}
+
+	// Groups aggregate the period views of their members
+	g := GroupMetrics(recent)[0]
+	if g.GetSuccessful() != 4 || g.GetFailed() != 1 || g.GetLoss() != 20 {
+		t.Errorf("Group over 1m: successful=%d failed=%d loss=%.1f, want 4, 1 and 20", g.GetSuccessful(), g.GetFailed(), g.GetLoss())
+	}
+	whole := GroupMetrics([]Metrics{a, b})[0]
+	if got := whole.GetAverageRTTInPeriod(15 * time.Minute); got != 20*time.Millisecond {
+		t.Errorf("Group GetAverageRTTInPeriod(15m) = %v, want 20ms", got)
+	}
+	if got := whole.GetStdDevInPeriod(15 * time.Minute); got != 10*time.Millisecond {
+		t.Errorf("Group GetStdDevInPeriod(15m) = %v, want 10ms", got)
+	}
+}
+
+func TestOutageTracking(t *testing.T) {
+	mm := metricsManager{
+		metrics:     make(map[string]*metrics),
+		historySize: DefaultHistorySize,
+	}
+	mm.SetOutageThresholds(3, 2)
+	host := "192.0.2.1"
+	mm.Register(host, host)
+
+	start := time.Now().Add(-time.Minute)
+	at := func(i int) time.Time { return start.Add(time.Duration(i) * time.Second) }
+	mm.Success(host, time.Millisecond, at(0), nil)
+	mm.Failed(host, at(1), "timeout") // Too short for an outage
+	mm.Failed(host, at(2), "timeout")
+	mm.Success(host, time.Millisecond, at(3), nil)
+	mm.Failed(host, at(4), "connection refused")
+	mm.Failed(host, at(5), "timeout")
+	mm.Failed(host, at(6), "timeout")
+	mm.Success(host, time.Millisecond, at(7), nil) // A single success does not close it
+	mm.Failed(host, at(8), "timeout")
+	mm.Success(host, time.Millisecond, at(9), nil)
+	mm.Success(host, time.Millisecond, at(10), nil)
+
+	m := mm.GetMetrics(host)
+	incidents := m.GetIncidents()
+	if m.GetOutages() != 1 || len(incidents) != 1 {
+		t.Fatalf("Expected 1 outage, got %d (%d kept)", m.GetOutages(), len(incidents))
+	}
+	i := incidents[0]
+	if !i.Start.Equal(at(4)) || !i.End.Equal(at(9)) || i.FirstError != "connection refused" || i.Ongoing() {
+		t.Errorf("Unexpected incident: %+v", i)
+	}
+	if m.GetMTTR() != 5*time.Second || m.GetDowntime() != 5*time.Second {
+		t.Errorf("Expected MTTR and downtime of 5s, got %v and %v", m.GetMTTR(), m.GetDowntime())
+	}
+	if got := m.GetAvailability(); got != 50 {
+		t.Errorf("GetAvailability() = %.1f, want 50", got)
+	}
+
+	// An ongoing outage counts up to the last probe
+	for j := 11; j < 14; j++ {
+		mm.Failed(host, at(j), "timeout")
+	}
+	if incidents := m.GetIncidents(); len(incidents) != 2 || !incidents[0].Ongoing() || !incidents[0].Start.Equal(at(11)) {
+		t.Fatalf("Expected an ongoing outage first, got %+v", incidents)
+	}
+	if m.GetDowntime() != 7*time.Second || m.GetMTTR() != 5*time.Second {
+		t.Errorf("Expected downtime 7s and MTTR 5s, got %v and %v", m.GetDowntime(), m.GetMTTR())
+	}
+
+	mm.ResetAllMetrics()
+	if m.GetOutages() != 0 || len(m.GetIncidents()) != 0 {
+		t.Error("Expected Reset() to clear outages")
+	}
+}
+
+func TestOutageRing(t *testing.T) {
+	var o outageTracker
+	o.failuresToOpen, o.successesToClose = 1, 1
+	now := time.Now()
+	for i := 0; i < maxIncidents+5; i++ {
+		o.Fail(now.Add(time.Duration(2*i)*time.Second), fmt.Sprintf("error %d", i))
+		o.Success(now.Add(time.Duration(2*i+1) * time.Second))
+	}
+	incidents := o.Incidents()
+	if o.count != maxIncidents+5 || len(incidents) != maxIncidents {
+		t.Fatalf("Expected %d outages with %d kept, got %d and %d", maxIncidents+5, maxIncidents, o.count, len(incidents))
+	}
+	if want := fmt.Sprintf("error %d", maxIncidents+4); incidents[0].FirstError != want {
+		t.Errorf("Expected newest incident %q first, got %q", want, incidents[0].FirstError)
+	}
+	if o.MTTR() != time.Second {
+		t.Errorf("MTTR() = %v, want 1s", o.MTTR())
+	}
+}
+
+func TestWindowAndRollupsOfOldResults(t *testing.T) {
+	// A replayed recording carries its own, past probe times
+	start := time.Date(2024, 1, 1, 15, 0, 0, 0, time.UTC)
+	m := &metrics{Name: "a", history: NewTargetHistory(10)}
+	for i := range 3 * 60 {
+		at := start.Add(time.Duration(i) * time.Second)
+		if i%10 == 0 {
+			m.Fail(at, "timeout")
+		} else {
+			m.Success(10*time.Millisecond, at)
+		}
+	}
+	// An old timeout delivered after newer results stays in its own slot
+	m.Fail(start.Add(175*time.Second), "timeout")
+
+	if got := m.GetSuccessfulInPeriod(time.Minute); got != 54 {
+		t.Errorf("GetSuccessfulInPeriod(1m) = %d, want 54", got)
+	}
+	if got := m.GetFailedInPeriod(time.Minute); got != 7 {
+		t.Errorf("GetFailedInPeriod(1m) = %d, want 7", got)
+	}
+	minutes := m.GetRollups(time.Minute, 3)
+	if len(minutes) != 3 || !minutes[0].Start.Equal(start) {
+		t.Fatalf("Expected 3 per-minute rollups from %v, got %+v", start, minutes)
+	}
+	if r := minutes[2]; r.Successful != 54 || r.Failed != 7 {
+		t.Errorf("Unexpected last minute: %+v", r)
+	}
+	if r := m.GetRollups(time.Hour, 1)[0]; !r.Start.Equal(start) || r.Total() != 181 {
+		t.Errorf("Unexpected hour rollup: %+v", r)
+	}
+}
+
+func TestRollups(t *testing.T) {
+	minute := time.Now().Truncate(time.Minute)
+	hour := time.Now().Truncate(time.Hour)
+	a := &metrics{Name: "a", Group: "example.com", history: NewTargetHistory(10)}
+	a.Success(10*time.Millisecond, minute)
+	a.Success(30*time.Millisecond, minute.Add(-2*time.Minute))
+	a.Fail(minute.Add(-2*time.Minute), "timeout")
+	a.Fail(hour.Add(-3*time.Hour), "timeout")
+	a.Fail(hour.Add(-8*24*time.Hour), "timeout") // Older than the per-hour rollups
+
+	minutes := a.GetRollups(time.Minute, 5)
+	hours := a.GetRollups(time.Hour, 1000)
+	if !time.Now().Truncate(time.Minute).Equal(minute) {
+		t.Skip("The minute changed while recording")
+	}
+	if len(minutes) != 5 || !minutes[4].Start.Equal(minute) || !minutes[2].Start.Equal(minute.Add(-2*time.Minute)) {
+		t.Fatalf("Expected 5 per-minute rollups ending at %v, got %+v", minute, minutes)
+	}
+	if minutes[4].Successful != 1 || minutes[4].AverageRTT != 10*time.Millisecond {
+		t.Errorf("Unexpected current minute: %+v", minutes[4])
+	}
+	if r := minutes[2]; r.Total() != 2 || r.Loss() != 50 || r.MinimumRTT != 30*time.Millisecond || r.MaximumRTT != 30*time.Millisecond {
+		t.Errorf("Unexpected rollup of 2 minutes ago: %+v", r)
+	}
+	if minutes[3].Total() != 0 || minutes[3].Loss() != 0 {
+		t.Errorf("Expected an empty rollup for a minute without probes, got %+v", minutes[3])
+	}
+
+	if len(hours) != rollupHours {
+		t.Fatalf("Expected the per-hour rollups to be capped at %d, got %d", rollupHours, len(hours))
+	}
+	if r := hours[len(hours)-4]; !r.Start.Equal(hour.Add(-3*time.Hour)) || r.Failed != 1 {
+		t.Errorf("Unexpected rollup of 3 hours ago: %+v", r)
+	}
+	total := 0
+	for _, r := range hours {
+		total += r.Total()
+	}
+	if total != 4 {
+		t.Errorf("Expected 4 probes in the per-hour rollups, got %d", total)
+	}
+	if a.GetRollups(time.Second, 5) != nil {
+		t.Error("Expected no rollups for an unsupported resolution")
+	}
+
+	// Groups merge their members period by period
+	b := &metrics{Name: "b", Group: "example.com", history: NewTargetHistory(10)}
+	b.Success(50*time.Millisecond, minute)
+	g := GroupMetrics([]Metrics{a, b})[0]
+	if r := g.GetRollups(time.Minute, 1)[0]; r.Successful != 2 || r.AverageRTT != 30*time.Millisecond || r.MinimumRTT != 10*time.Millisecond || r.MaximumRTT != 50*time.Millisecond {
+		t.Errorf("Unexpected group rollup: %+v", r)
+	}
+
+	a.Reset()
+	if r := a.GetRollups(time.Minute, 1)[0]; r.Total() != 0 {
+		t.Errorf("Expected Reset() to clear rollups, got %+v", r)
+	}
+}
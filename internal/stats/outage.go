@@ -0,0 +1,166 @@
+package stats
+
+import (
+	"sort"
+	"time"
+)
+
+const (
+	DefaultOutageFailures   = 3  // Consecutive failures that open an incident
+	DefaultOutageRecoveries = 3  // Consecutive successes that close it
+	maxIncidents            = 20 // Recent incidents kept per target
+)
+
+// Incident is a period in which a target was down
+type Incident struct {
+	Start      time.Time `json:"start"`                 // Send time of the first failed probe
+	End        time.Time `json:"end"`                   // Send time of the first probe of the recovery; zero while ongoing
+	FirstError string    `json:"first_error,omitempty"` // Error of the first failed probe
+}
+
+// Ongoing reports whether the target has not recovered yet
+func (i Incident) Ongoing() bool {
+	return i.End.IsZero()
+}
+
+// Duration returns how long the incident lasted, or has lasted so far
+func (i Incident) Duration() time.Duration {
+	if i.Ongoing() {
+		return time.Since(i.Start)
+	}
+	return i.End.Sub(i.Start)
+}
+
+// outageTracker turns probe results into incidents. The incidents are kept in
+// a fixed ring so that readers never see a partially grown slice.
+type outageTracker struct {
+	failuresToOpen   int // 0 means DefaultOutageFailures
+	successesToClose int // 0 means DefaultOutageRecoveries
+	failures         int
+	successes        int
+	streakStart      time.Time // First probe of the current failure or success streak
+	streakError      string    // First error of the current failure streak
+	open             bool
+	incidents        [maxIncidents]Incident
+	next             int // Ring index of the next incident
+	count            int // All incidents, including those dropped from the ring
+	closed           int
+	closedDowntime   time.Duration
+	firstSeen        time.Time
+	lastSeen         time.Time
+	unobserved       time.Duration // Gaps between runs, which are neither uptime nor downtime
+	resumed          bool          // Restored from a saved state; the next probe ends a gap
+}
+
+func (o *outageTracker) thresholds() (int, int) {
+	failures, successes := o.failuresToOpen, o.successesToClose
+	if failures <= 0 {
+		failures = DefaultOutageFailures
+	}
+	if successes <= 0 {
+		successes = DefaultOutageRecoveries
+	}
+	return failures, successes
+}
+
+func (o *outageTracker) seen(t time.Time) {
+	if o.resumed && t.After(o.lastSeen) {
+		o.unobserved += t.Sub(o.lastSeen)
+	}
+	o.resumed = false
+	if o.firstSeen.IsZero() || t.Before(o.firstSeen) {
+		o.firstSeen = t
+	}
+	if t.After(o.lastSeen) {
+		o.lastSeen = t
+	}
+}
+
+func (o *outageTracker) Success(t time.Time) {
+	o.seen(t)
+	o.failures = 0
+	o.successes++
+	if o.successes == 1 {
+		o.streakStart = t
+	}
+	if _, successesToClose := o.thresholds(); o.open && o.successes >= successesToClose {
+		cur := &o.incidents[(o.next+maxIncidents-1)%maxIncidents]
+		cur.End = o.streakStart
+		o.open = false
+		o.closed++
+		o.closedDowntime += cur.End.Sub(cur.Start)
+	}
+}
+
+func (o *outageTracker) Fail(t time.Time, msg string) {
+	o.seen(t)
+	o.successes = 0
+	o.failures++
+	if o.failures == 1 {
+		o.streakStart = t
+		o.streakError = msg
+	}
+	if failuresToOpen, _ := o.thresholds(); !o.open && o.failures >= failuresToOpen {
+		o.incidents[o.next] = Incident{Start: o.streakStart, FirstError: o.streakError}
+		o.next = (o.next + 1) % maxIncidents
+		o.count++
+		o.open = true
+	}
+}
+
+// Reset clears all incidents but keeps the thresholds
+func (o *outageTracker) Reset() {
+	*o = outageTracker{failuresToOpen: o.failuresToOpen, successesToClose: o.successesToClose}
+}
+
+// Incidents returns the kept incidents, newest first
+func (o *outageTracker) Incidents() []Incident {
+	n := min(o.count, maxIncidents)
+	res := make([]Incident, n)
+	for i := range res {
+		res[i] = o.incidents[(o.next+maxIncidents-1-i)%maxIncidents]
+	}
+	return res
+}
+
+// Downtime returns the total time in incidents; an ongoing incident counts up to the last probe
+func (o *outageTracker) Downtime() time.Duration {
+	d := o.closedDowntime
+	if o.open {
+		cur := o.incidents[(o.next+maxIncidents-1)%maxIncidents]
+		d += o.lastSeen.Sub(cur.Start)
+	}
+	return d
+}
+
+// MTTR returns the mean duration of the closed incidents
+func (o *outageTracker) MTTR() time.Duration {
+	if o.closed == 0 {
+		return 0
+	}
+	return o.closedDowntime / time.Duration(o.closed)
+}
+
+// Availability returns the percentage of the observed time not spent in incidents
+func (o *outageTracker) Availability() float64 {
+	span := o.lastSeen.Sub(o.firstSeen) - o.unobserved
+	if span <= 0 {
+		if o.open {
+			return 0
+		}
+		return 100
+	}
+	return 100 - float64(o.Downtime())/float64(span)*100
+}
+
+// mergeIncidents combines incident lists, newest first
+func mergeIncidents(lists ...[]Incident) []Incident {
+	var res []Incident
+	for _, l := range lists {
+		res = append(res, l...)
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Start.After(res[j].Start)
+	})
+	return res
+}
@@ -0,0 +1,122 @@
+package stats
+
+import "time"
+
+const (
+	rollupMinutes = 6 * 60 // Per-minute rollups kept for 6 hours
+	rollupHours   = 7 * 24 // Per-hour rollups kept for 7 days
+)
+
+// Rollup summarizes the probes sent in one minute or hour
+type Rollup struct {
+	Start      time.Time
+	Successful int
+	Failed     int
+	MinimumRTT time.Duration
+	AverageRTT time.Duration
+	MaximumRTT time.Duration
+}
+
+func (r Rollup) Total() int {
+	return r.Successful + r.Failed
+}
+
+// Loss returns the loss rate in percent, or 0 without probes
+func (r Rollup) Loss() float64 {
+	if r.Total() == 0 {
+		return 0
+	}
+	return float64(r.Failed) / float64(r.Total()) * 100
+}
+
+// rollups downsamples probe results into per-minute and per-hour buckets, so
+// that history older than the TargetHistory ring buffer is kept with bounded memory
+type rollups struct {
+	minutes [rollupMinutes]resultBucket
+	hours   [rollupHours]resultBucket
+}
+
+func (r *rollups) Success(t, now time.Time, rtt time.Duration) {
+	if b := bucketAt(r.minutes[:], time.Minute, t, now); b != nil {
+		b.success(rtt)
+	}
+	if b := bucketAt(r.hours[:], time.Hour, t, now); b != nil {
+		b.success(rtt)
+	}
+}
+
+func (r *rollups) Fail(t, now time.Time) {
+	if b := bucketAt(r.minutes[:], time.Minute, t, now); b != nil {
+		b.failed++
+	}
+	if b := bucketAt(r.hours[:], time.Hour, t, now); b != nil {
+		b.failed++
+	}
+}
+
+func (r *rollups) Reset() {
+	*r = rollups{}
+}
+
+// Get returns the last n rollups of resolution (time.Minute or time.Hour),
+// oldest first and ending with the one containing now. Periods without
+// probes are included as empty rollups so that the series is continuous.
+func (r *rollups) Get(now time.Time, resolution time.Duration, n int) []Rollup {
+	var buckets []resultBucket
+	switch resolution {
+	case time.Minute:
+		buckets = r.minutes[:]
+	case time.Hour:
+		buckets = r.hours[:]
+	default:
+		return nil
+	}
+	n = min(n, len(buckets))
+	last := now.UnixNano() / int64(resolution)
+	res := make([]Rollup, max(n, 0))
+	for i := range res {
+		slot := last - int64(n-1-i)
+		res[i] = Rollup{Start: time.Unix(0, slot*int64(resolution))}
+		if b := &buckets[slot%int64(len(buckets))]; b.slot == slot {
+			res[i].add(b.successful, b.failed, b.totalRTT, b.minRTT, b.maxRTT)
+		}
+	}
+	return res
+}
+
+// add merges results into r
+func (r *Rollup) add(successful, failed int, totalRTT, minRTT, maxRTT time.Duration) {
+	if successful > 0 {
+		r.AverageRTT = (r.AverageRTT*time.Duration(r.Successful) + totalRTT) / time.Duration(r.Successful+successful)
+	}
+	r.Successful += successful
+	r.Failed += failed
+	if minRTT != 0 && (r.MinimumRTT == 0 || minRTT < r.MinimumRTT) {
+		r.MinimumRTT = minRTT
+	}
+	if maxRTT > r.MaximumRTT {
+		r.MaximumRTT = maxRTT
+	}
+}
+
+// mergeRollups combines series of the same resolution slot by slot, over the
+// periods of the first series
+func mergeRollups(series ...[]Rollup) []Rollup {
+	if len(series) == 0 {
+		return nil
+	}
+	res := make([]Rollup, len(series[0]))
+	index := make(map[int64]int, len(res))
+	for i, r := range series[0] {
+		res[i].Start = r.Start
+		index[r.Start.UnixNano()] = i
+	}
+	for _, s := range series {
+		for _, o := range s {
+			if i, ok := index[o.Start.UnixNano()]; ok {
+				res[i].add(o.Successful, o.Failed, o.AverageRTT*time.Duration(o.Successful), o.MinimumRTT, o.MaximumRTT)
+			}
+		}
+	}
+	return res
+}
@@ -0,0 +1,90 @@
+package stats
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// sketchGamma sets the bucket width of rttSketch; quantiles are accurate to
+	// within (gamma-1)/(gamma+1), about 1%, of the true value
+	sketchGamma = 1.02
+	// sketchMin and sketchBuckets cover 1µs to about 100s; values outside are clamped
+	sketchMin     = time.Microsecond
+	sketchBuckets = 931
+)
+
+var sketchLogGamma = math.Log(sketchGamma)
+
+// rttSketch is a streaming quantile estimator over logarithmic buckets
+// (DDSketch-style). It covers every sample rather than only the history ring
+// buffer and can be merged, which lets group rows report percentiles over all
+// of their members. The buckets are a fixed array so that readers never see
+// a partially grown slice.
+type rttSketch struct {
+	counts [sketchBuckets]uint32
+	lo, hi int // Range of non-empty buckets, valid when total > 0
+	total  uint64
+}
+
+// sketchIndex returns the bucket for rtt
+func sketchIndex(rtt time.Duration) int {
+	if rtt <= sketchMin {
+		return 0
+	}
+	i := int(math.Ceil(math.Log(float64(rtt)/float64(sketchMin)) / sketchLogGamma))
+	return min(i, sketchBuckets-1)
+}
+
+// sketchValue returns the representative value of bucket i, which is within
+// the relative error bound of every value in the bucket
+func sketchValue(i int) time.Duration {
+	return time.Duration(math.Round(float64(sketchMin) * 2 * math.Pow(sketchGamma, float64(i)) / (sketchGamma + 1)))
+}
+
+func (s *rttSketch) Add(rtt time.Duration) {
+	s.add(sketchIndex(rtt), 1)
+}
+
+func (s *rttSketch) add(i int, n uint32) {
+	if s.total == 0 || i < s.lo {
+		s.lo = i
+	}
+	if s.total == 0 || i > s.hi {
+		s.hi = i
+	}
+	s.counts[i] += n
+	s.total += uint64(n)
+}
+
+// Merge adds all samples of o to s
+func (s *rttSketch) Merge(o *rttSketch) {
+	if o.total == 0 {
+		return
+	}
+	for i := o.lo; i <= o.hi; i++ {
+		if o.counts[i] > 0 {
+			s.add(i, o.counts[i])
+		}
+	}
+}
+
+// Quantile returns the estimated q-quantile (0 <= q <= 1), or 0 without samples
+func (s *rttSketch) Quantile(q float64) time.Duration {
+	if s.total == 0 {
+		return 0
+	}
+	rank := uint64(q * float64(s.total-1))
+	var seen uint64
+	for i := s.lo; i <= s.hi; i++ {
+		seen += uint64(s.counts[i])
+		if seen > rank {
+			return sketchValue(i)
+		}
+	}
+	return sketchValue(s.hi)
+}
+
+func (s *rttSketch) Reset() {
+	*s = rttSketch{}
+}
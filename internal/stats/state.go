@@ -0,0 +1,337 @@
+package stats
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// stateVersion is the format version of state files; other versions are rejected
+const stateVersion = 1
+
+// stateFile is the on-disk form of all targets' metrics, keyed by target key
+type stateFile struct {
+	Version int                     `json:"version"`
+	SavedAt time.Time               `json:"saved_at"`
+	Targets map[string]*targetState `json:"targets"`
+}
+
+// targetState is the persisted form of a target's metrics. The display name,
+// group and family come from the new registration and are not restored.
+type targetState struct {
+	Name           string         `json:"name"`
+	Total          int            `json:"total"`
+	Successful     int            `json:"successful"`
+	Failed         int            `json:"failed"`
+	TotalRTT       time.Duration  `json:"total_rtt"`
+	MinimumRTT     time.Duration  `json:"minimum_rtt"`
+	MaximumRTT     time.Duration  `json:"maximum_rtt"`
+	LastRTT        time.Duration  `json:"last_rtt"`
+	LastSuccTime   time.Time      `json:"last_succ_time"`
+	LastFailTime   time.Time      `json:"last_fail_time"`
+	LastFailDetail string         `json:"last_fail_detail,omitempty"`
+	Duplicates     int            `json:"duplicates,omitempty"`
+	Reordered      int            `json:"reordered,omitempty"`
+	Late           int            `json:"late,omitempty"`
+	Jitter         float64        `json:"jitter"`
+	RTTMean        float64        `json:"rtt_mean"`
+	RTTM2          float64        `json:"rtt_m2"`
+	Sketch         sketchState    `json:"sketch"`
+	History        []HistoryEntry `json:"history,omitempty"` // Oldest first
+	Window         []bucketState  `json:"window,omitempty"`
+	Minutes        []bucketState  `json:"minutes,omitempty"`
+	Hours          []bucketState  `json:"hours,omitempty"`
+	Outage         outageState    `json:"outage"`
+}
+
+// sketchState holds the non-empty range of an rttSketch
+type sketchState struct {
+	Offset int      `json:"offset"`
+	Counts []uint32 `json:"counts,omitempty"`
+}
+
+type bucketState struct {
+	Slot       int64         `json:"slot"`
+	Successful int           `json:"successful"`
+	Failed     int           `json:"failed"`
+	TotalRTT   time.Duration `json:"total_rtt"`
+	SumSquares float64       `json:"sum_squares"`
+	MinRTT     time.Duration `json:"min_rtt"`
+	MaxRTT     time.Duration `json:"max_rtt"`
+}
+
+type outageState struct {
+	Failures       int           `json:"failures"`
+	Successes      int           `json:"successes"`
+	StreakStart    time.Time     `json:"streak_start"`
+	StreakError    string        `json:"streak_error,omitempty"`
+	Open           bool          `json:"open"`
+	Incidents      []Incident    `json:"incidents,omitempty"` // Oldest first
+	Count          int           `json:"count"`
+	Closed         int           `json:"closed"`
+	ClosedDowntime time.Duration `json:"closed_downtime"`
+	FirstSeen      time.Time     `json:"first_seen"`
+	LastSeen       time.Time     `json:"last_seen"`
+	Unobserved     time.Duration `json:"unobserved,omitempty"`
+}
+
+// SaveState writes all targets to path, replacing the file atomically so that
+// a crash during the write keeps the previous snapshot
+func (mm *metricsManager) SaveState(path string) error {
+	mm.mu.Lock()
+	st := stateFile{
+		Version: stateVersion,
+		SavedAt: time.Now(),
+		Targets: make(map[string]*targetState, len(mm.metrics)+len(mm.restored)),
+	}
+	for key, m := range mm.metrics {
+		st.Targets[key] = m.state()
+	}
+	// Keep restored targets that are not probed in this run for a later one
+	for key, t := range mm.restored {
+		if _, ok := st.Targets[key]; !ok {
+			st.Targets[key] = t
+		}
+	}
+	mm.mu.Unlock()
+
+	data, err := json.Marshal(st)
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create state file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace state file: %w", err)
+	}
+	return nil
+}
+
+// LoadState reads a file written by SaveState. Each saved target is restored
+// when a target with the same key is registered. A missing file is not an error.
+func (mm *metricsManager) LoadState(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read state file: %w", err)
+	}
+	var st stateFile
+	if err := json.Unmarshal(data, &st); err != nil {
+		return fmt.Errorf("failed to parse state file %s: %w", path, err)
+	}
+	if st.Version != stateVersion {
+		return fmt.Errorf("unsupported state file version %d (expected %d)", st.Version, stateVersion)
+	}
+
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	mm.restored = st.Targets
+	for key, m := range mm.metrics {
+		mm.restore(key, m)
+	}
+	return nil
+}
+
+// restore applies the saved state of key to m, once; mm.mu must be held
+func (mm *metricsManager) restore(key string, m *metrics) {
+	if t, ok := mm.restored[key]; ok && t != nil {
+		m.restore(t)
+		delete(mm.restored, key)
+	}
+}
+
+func (m *metrics) state() *targetState {
+	t := &targetState{
+		Name:           m.Name,
+		Total:          m.Total,
+		Successful:     m.Successful,
+		Failed:         m.Failed,
+		TotalRTT:       m.TotalRTT,
+		MinimumRTT:     m.MinimumRTT,
+		MaximumRTT:     m.MaximumRTT,
+		LastRTT:        m.LastRTT,
+		LastSuccTime:   m.LastSuccTime,
+		LastFailTime:   m.LastFailTime,
+		LastFailDetail: m.LastFailDetail,
+		Duplicates:     m.Duplicates,
+		Reordered:      m.Reordered,
+		Late:           m.Late,
+		Jitter:         m.jitter,
+		RTTMean:        m.rttMean,
+		RTTM2:          m.rttM2,
+		Window:         bucketStates(m.window.buckets[:]),
+		Minutes:        bucketStates(m.rollups.minutes[:]),
+		Hours:          bucketStates(m.rollups.hours[:]),
+		Outage:         m.outage.state(),
+	}
+	if m.sketch.total > 0 {
+		t.Sketch = sketchState{
+			Offset: m.sketch.lo,
+			Counts: append([]uint32(nil), m.sketch.counts[m.sketch.lo:m.sketch.hi+1]...),
+		}
+	}
+	if m.history != nil {
+		entries := m.history.GetRecentEntries(m.history.size)
+		for i := len(entries) - 1; i >= 0; i-- {
+			t.History = append(t.History, entries[i])
+		}
+	}
+	return t
+}
+
+func (m *metrics) restore(t *targetState) {
+	m.Total = t.Total
+	m.Successful = t.Successful
+	m.Failed = t.Failed
+	m.TotalRTT = t.TotalRTT
+	m.MinimumRTT = t.MinimumRTT
+	m.MaximumRTT = t.MaximumRTT
+	m.LastRTT = t.LastRTT
+	m.LastSuccTime = t.LastSuccTime
+	m.LastFailTime = t.LastFailTime
+	m.LastFailDetail = t.LastFailDetail
+	m.Duplicates = t.Duplicates
+	m.Reordered = t.Reordered
+	m.Late = t.Late
+	m.jitter = t.Jitter
+	m.rttMean = t.RTTMean
+	m.rttM2 = t.RTTM2
+	if m.Successful > 0 {
+		m.AverageRTT = m.TotalRTT / time.Duration(m.Successful)
+		m.Jitter = time.Duration(math.Round(m.jitter))
+		m.StdDev = time.Duration(math.Round(math.Sqrt(m.rttM2 / float64(m.Successful))))
+	}
+	if m.Successful+m.Failed > 0 {
+		m.loss()
+	}
+
+	m.sketch.Reset()
+	for i, n := range t.Sketch.Counts {
+		if j := t.Sketch.Offset + i; n > 0 && j >= 0 && j < sketchBuckets {
+			m.sketch.add(j, n)
+		}
+	}
+	if m.history != nil {
+		m.history.Clear()
+		for _, e := range t.History {
+			m.history.AddEntry(e)
+		}
+	}
+	m.clock = time.Time{}
+	m.tick(t.LastSuccTime)
+	m.tick(t.LastFailTime)
+	restoreBuckets(m.window.buckets[:], t.Window)
+	restoreBuckets(m.rollups.minutes[:], t.Minutes)
+	restoreBuckets(m.rollups.hours[:], t.Hours)
+	m.outage.restore(t.Outage)
+}
+
+// bucketStates returns the buckets in use
+func bucketStates(buckets []resultBucket) []bucketState {
+	var res []bucketState
+	for _, b := range buckets {
+		if b.successful+b.failed == 0 {
+			continue
+		}
+		res = append(res, bucketState{
+			Slot:       b.slot,
+			Successful: b.successful,
+			Failed:     b.failed,
+			TotalRTT:   b.totalRTT,
+			SumSquares: b.sumSquares,
+			MinRTT:     b.minRTT,
+			MaxRTT:     b.maxRTT,
+		})
+	}
+	return res
+}
+
+func restoreBuckets(buckets []resultBucket, states []bucketState) {
+	for _, s := range states {
+		if s.Slot <= 0 {
+			continue
+		}
+		buckets[s.Slot%int64(len(buckets))] = resultBucket{
+			slot:       s.Slot,
+			successful: s.Successful,
+			failed:     s.Failed,
+			totalRTT:   s.TotalRTT,
+			sumSquares: s.SumSquares,
+			minRTT:     s.MinRTT,
+			maxRTT:     s.MaxRTT,
+		}
+	}
+}
+
+func (o *outageTracker) state() outageState {
+	incidents := o.Incidents()
+	for i, j := 0, len(incidents)-1; i < j; i, j = i+1, j-1 {
+		incidents[i], incidents[j] = incidents[j], incidents[i]
+	}
+	s := outageState{
+		Failures:       o.failures,
+		Successes:      o.successes,
+		StreakStart:    o.streakStart,
+		StreakError:    o.streakError,
+		Open:           o.open,
+		Incidents:      incidents,
+		Count:          o.count,
+		Closed:         o.closed,
+		ClosedDowntime: o.closedDowntime,
+		FirstSeen:      o.firstSeen,
+		LastSeen:       o.lastSeen,
+		Unobserved:     o.unobserved,
+	}
+	if o.open {
+		// Close an ongoing incident at the last probe. What happens until the
+		// next run is not observed, so it must count as neither down nor up.
+		cur := &s.Incidents[len(s.Incidents)-1]
+		cur.End = o.lastSeen
+		s.Open = false
+		s.Closed++
+		s.ClosedDowntime += cur.End.Sub(cur.Start)
+		s.Failures = 0
+	}
+	return s
+}
+
+// restore replaces the tracker's state but keeps its thresholds
+func (o *outageTracker) restore(s outageState) {
+	o.Reset()
+	for _, i := range s.Incidents {
+		o.incidents[o.next] = i
+		o.next = (o.next + 1) % maxIncidents
+	}
+	o.failures = s.Failures
+	o.successes = s.Successes
+	o.streakStart = s.StreakStart
+	o.streakError = s.StreakError
+	o.open = s.Open && len(s.Incidents) > 0
+	o.count = max(s.Count, len(s.Incidents))
+	o.closed = s.Closed
+	o.closedDowntime = s.ClosedDowntime
+	o.firstSeen = s.FirstSeen
+	o.lastSeen = s.LastSeen
+	o.unobserved = s.Unobserved
+	o.resumed = !s.LastSeen.IsZero()
+}
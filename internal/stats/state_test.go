@@ -0,0 +1,146 @@
+package stats
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSaveAndLoadState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	now := time.Now().Add(-10 * time.Second) // In the past, so that the rolling windows include every result
+
+	mm := NewMetricsManager().(*metricsManager)
+	mm.SetOutageThresholds(1, 1)
+	mm.autoRegister("192.0.2.1", "example.com(192.0.2.1)", "", "")
+	mm.autoRegister("192.0.2.2", "192.0.2.2", "", "")
+	for i, rtt := range []time.Duration{10, 20, 30, 40} {
+		mm.Sent("192.0.2.1")
+		mm.Success("192.0.2.1", rtt*time.Millisecond, now.Add(time.Duration(i)*time.Second), nil)
+	}
+	mm.Sent("192.0.2.1")
+	mm.Failed("192.0.2.1", now.Add(5*time.Second), "timeout")
+	mm.Failed("192.0.2.2", now, "timeout")
+	if err := mm.SaveState(path); err != nil {
+		t.Fatalf("SaveState() error = %v", err)
+	}
+	want := mm.GetMetrics("192.0.2.1")
+
+	restored := NewMetricsManager().(*metricsManager)
+	restored.SetOutageThresholds(1, 1)
+	if err := restored.LoadState(path); err != nil {
+		t.Fatalf("LoadState() error = %v", err)
+	}
+	// Only registered targets are restored; the new display name is kept
+	restored.autoRegister("192.0.2.1", "example.com(192.0.2.10)", "", "")
+	if ms := restored.SortBy(Host, true); len(ms) != 1 {
+		t.Fatalf("Expected 1 restored target, got %d", len(ms))
+	}
+	got := restored.GetMetrics("192.0.2.1")
+	if got.GetName() != "example.com(192.0.2.10)" {
+		t.Errorf("Expected the new display name, got %s", got.GetName())
+	}
+	if got.GetTotal() != 5 || got.GetSuccessful() != 4 || got.GetFailed() != 1 || got.GetLoss() != want.GetLoss() {
+		t.Errorf("Counters not restored: total=%d successful=%d failed=%d loss=%.1f", got.GetTotal(), got.GetSuccessful(), got.GetFailed(), got.GetLoss())
+	}
+	if got.GetAverageRTT() != want.GetAverageRTT() || got.GetStdDev() != want.GetStdDev() || got.GetJitter() != want.GetJitter() || got.GetPercentileRTT(0.9) != want.GetPercentileRTT(0.9) {
+		t.Errorf("RTT statistics not restored: avg=%v stddev=%v jitter=%v p90=%v", got.GetAverageRTT(), got.GetStdDev(), got.GetJitter(), got.GetPercentileRTT(0.9))
+	}
+	if h := got.GetRecentHistory(10); len(h) != 5 || h[0].Success || h[1].RTT != 40*time.Millisecond {
+		t.Errorf("History not restored in order: %+v", h)
+	}
+	rolledUp := 0
+	for _, r := range got.GetRollups(time.Hour, 2) {
+		rolledUp += r.Total()
+	}
+	if got.GetSuccessfulInPeriod(time.Minute) != 4 || rolledUp != 5 {
+		t.Errorf("Windows or rollups not restored: %d in the last minute, %d rolled up", got.GetSuccessfulInPeriod(time.Minute), rolledUp)
+	}
+	if got.GetOutages() != 1 || len(got.GetIncidents()) != 1 || got.GetIncidents()[0].FirstError != "timeout" {
+		t.Errorf("Outages not restored: %+v", got.GetIncidents())
+	}
+
+	// Further results continue from the restored state
+	restored.Success("192.0.2.1", 50*time.Millisecond, now.Add(6*time.Second), nil)
+	if got.GetSuccessful() != 5 || got.GetAverageRTT() != 30*time.Millisecond || got.GetOutages() != 1 || got.GetIncidents()[0].Ongoing() {
+		t.Errorf("Unexpected metrics after restore: successful=%d avg=%v incidents=%+v", got.GetSuccessful(), got.GetAverageRTT(), got.GetIncidents())
+	}
+
+	// Targets not probed in this run are kept for a later one
+	if err := restored.SaveState(path); err != nil {
+		t.Fatalf("SaveState() error = %v", err)
+	}
+	later := NewMetricsManager().(*metricsManager)
+	if err := later.LoadState(path); err != nil {
+		t.Fatalf("LoadState() error = %v", err)
+	}
+	if later.GetMetrics("192.0.2.2").GetFailed() != 1 {
+		t.Error("Expected the unregistered target to survive another save")
+	}
+
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("Expected only the state file to remain, got %d files", len(entries))
+	}
+}
+
+func TestSaveStateWithOngoingIncident(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	start := time.Date(2024, 1, 1, 15, 0, 0, 0, time.UTC)
+
+	mm := NewMetricsManager().(*metricsManager)
+	mm.SetOutageThresholds(1, 1)
+	mm.autoRegister("192.0.2.1", "192.0.2.1", "", "")
+	mm.Success("192.0.2.1", 10*time.Millisecond, start, nil)
+	mm.Failed("192.0.2.1", start.Add(10*time.Second), "timeout")
+	mm.Failed("192.0.2.1", start.Add(20*time.Second), "timeout")
+	if err := mm.SaveState(path); err != nil {
+		t.Fatalf("SaveState() error = %v", err)
+	}
+	if !mm.GetMetrics("192.0.2.1").GetIncidents()[0].Ongoing() {
+		t.Error("Saving should not close the incident of the running instance")
+	}
+
+	restored := NewMetricsManager().(*metricsManager)
+	restored.SetOutageThresholds(1, 1)
+	if err := restored.LoadState(path); err != nil {
+		t.Fatalf("LoadState() error = %v", err)
+	}
+	restored.autoRegister("192.0.2.1", "192.0.2.1", "", "")
+	// The next run starts an hour later with the target up again
+	restored.Success("192.0.2.1", 10*time.Millisecond, start.Add(time.Hour), nil)
+
+	got := restored.GetMetrics("192.0.2.1")
+	incidents := got.GetIncidents()
+	if len(incidents) != 1 || !incidents[0].End.Equal(start.Add(20*time.Second)) {
+		t.Fatalf("Expected the incident to end at the last probe before the save, got %+v", incidents)
+	}
+	if got.GetDowntime() != 10*time.Second {
+		t.Errorf("GetDowntime() = %v, want 10s", got.GetDowntime())
+	}
+	// The hour between the runs is neither uptime nor downtime
+	if a := got.GetAvailability(); a != 50 {
+		t.Errorf("GetAvailability() = %.2f, want 50", a)
+	}
+}
+
+func TestLoadStateErrors(t *testing.T) {
+	dir := t.TempDir()
+	mm := NewMetricsManager()
+	if err := mm.LoadState(filepath.Join(dir, "missing.json")); err != nil {
+		t.Errorf("Expected a missing state file to be ignored, got %v", err)
+	}
+
+	path := filepath.Join(dir, "state.json")
+	os.WriteFile(path, []byte(`{"version": 99, "targets": {}}`), 0o644)
+	if err := mm.LoadState(path); err == nil || !strings.Contains(err.Error(), "unsupported state file version 99") {
+		t.Errorf("Expected a version error, got %v", err)
+	}
+
+	os.WriteFile(path, []byte(`{`), 0o644)
+	if err := mm.LoadState(path); err == nil {
+		t.Error("Expected an error for a corrupt state file")
+	}
+}
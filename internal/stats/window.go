@@ -0,0 +1,191 @@
+package stats
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	windowResolution = 5 * time.Second
+	// MaxStatsWindow is the longest period covered by the *InPeriod statistics
+	MaxStatsWindow = 15 * time.Minute
+	windowBuckets  = int(MaxStatsWindow/windowResolution) + 1 // One more for the current, partial slot
+)
+
+// StatsWindows are the periods the table can be switched to besides lifetime totals
+var StatsWindows = []time.Duration{time.Minute, 5 * time.Minute, 15 * time.Minute}
+
+// resultBucket aggregates the probe results of one time slot
+type resultBucket struct {
+	slot       int64 // Unix time / resolution; a bucket with another slot is stale
+	successful int
+	failed     int
+	totalRTT   time.Duration
+	sumSquares float64 // Sum of squared RTTs in nanoseconds, for the standard deviation
+	minRTT     time.Duration
+	maxRTT     time.Duration
+}
+
+func (b *resultBucket) success(rtt time.Duration) {
+	b.successful++
+	b.totalRTT += rtt
+	b.sumSquares += float64(rtt) * float64(rtt)
+	if b.minRTT == 0 || rtt < b.minRTT {
+		b.minRTT = rtt
+	}
+	if rtt > b.maxRTT {
+		b.maxRTT = rtt
+	}
+}
+
+// bucketAt returns the bucket for t in a ring of slots of the given resolution,
+// or nil if t is too old to be kept at now, the newest probe time. The ring is a
+// fixed array indexed by slot, so memory does not grow with the probe rate.
+func bucketAt(buckets []resultBucket, resolution time.Duration, t, now time.Time) *resultBucket {
+	slot := t.UnixNano() / int64(resolution)
+	if slot <= now.UnixNano()/int64(resolution)-int64(len(buckets)) {
+		return nil
+	}
+	b := &buckets[slot%int64(len(buckets))]
+	if b.slot != slot {
+		*b = resultBucket{slot: slot}
+	}
+	return b
+}
+
+// rollingWindow keeps per-slot results for the last MaxStatsWindow
+type rollingWindow struct {
+	buckets [windowBuckets]resultBucket
+}
+
+func (w *rollingWindow) Success(t, now time.Time, rtt time.Duration) {
+	if b := bucketAt(w.buckets[:], windowResolution, t, now); b != nil {
+		b.success(rtt)
+	}
+}
+
+func (w *rollingWindow) Fail(t, now time.Time) {
+	if b := bucketAt(w.buckets[:], windowResolution, t, now); b != nil {
+		b.failed++
+	}
+}
+
+func (w *rollingWindow) Reset() {
+	*w = rollingWindow{}
+}
+
+// Stats aggregates the slots of the period d (up to MaxStatsWindow) ending at now
+func (w *rollingWindow) Stats(now time.Time, d time.Duration) periodStats {
+	d = min(d, MaxStatsWindow)
+	last := now.UnixNano() / int64(windowResolution)
+	first := last - int64(d/windowResolution) + 1
+	var s periodStats
+	for i := range w.buckets {
+		b := &w.buckets[i]
+		if b.slot < first || b.slot > last {
+			continue
+		}
+		s.successful += b.successful
+		s.failed += b.failed
+		s.totalRTT += b.totalRTT
+		s.sumSquares += b.sumSquares
+		if b.minRTT != 0 && (s.minRTT == 0 || b.minRTT < s.minRTT) {
+			s.minRTT = b.minRTT
+		}
+		if b.maxRTT > s.maxRTT {
+			s.maxRTT = b.maxRTT
+		}
+	}
+	return s
+}
+
+// periodStats holds the results of a period
+type periodStats struct {
+	successful int
+	failed     int
+	totalRTT   time.Duration
+	sumSquares float64
+	minRTT     time.Duration
+	maxRTT     time.Duration
+}
+
+func (s periodStats) loss() float64 {
+	if s.successful+s.failed == 0 {
+		return 0
+	}
+	return float64(s.failed) / float64(s.successful+s.failed) * 100
+}
+
+func (s periodStats) averageRTT() time.Duration {
+	if s.successful == 0 {
+		return 0
+	}
+	return s.totalRTT / time.Duration(s.successful)
+}
+
+func (s periodStats) stdDev() time.Duration {
+	if s.successful == 0 {
+		return 0
+	}
+	n := float64(s.successful)
+	mean := float64(s.totalRTT) / n
+	return time.Duration(math.Round(math.Sqrt(math.Max(s.sumSquares/n-mean*mean, 0))))
+}
+
+// periodMetrics presents a target's statistics over a recent period instead of
+// its lifetime. Jitter and percentiles are only tracked for the lifetime and
+// are reported as unavailable.
+type periodMetrics struct {
+	Metrics
+	period time.Duration
+}
+
+// InPeriod returns views of ms that report statistics over the last period
+func InPeriod(ms []Metrics, period time.Duration) []Metrics {
+	res := make([]Metrics, len(ms))
+	for i, m := range ms {
+		res[i] = &periodMetrics{Metrics: m, period: period}
+	}
+	return res
+}
+
+// GetTotal returns the probes completed in the period
+func (p *periodMetrics) GetTotal() int {
+	return p.GetSuccessful() + p.GetFailed()
+}
+
+func (p *periodMetrics) GetSuccessful() int {
+	return p.Metrics.GetSuccessfulInPeriod(p.period)
+}
+
+func (p *periodMetrics) GetFailed() int {
+	return p.Metrics.GetFailedInPeriod(p.period)
+}
+
+func (p *periodMetrics) GetLoss() float64 {
+	return p.Metrics.GetLossInPeriod(p.period)
+}
+
+func (p *periodMetrics) GetAverageRTT() time.Duration {
+	return p.Metrics.GetAverageRTTInPeriod(p.period)
+}
+
+func (p *periodMetrics) GetMinimumRTT() time.Duration {
+	return p.Metrics.GetMinimumRTTInPeriod(p.period)
+}
+
+func (p *periodMetrics) GetMaximumRTT() time.Duration {
+	return p.Metrics.GetMaximumRTTInPeriod(p.period)
+}
+
+func (p *periodMetrics) GetStdDev() time.Duration {
+	return p.Metrics.GetStdDevInPeriod(p.period)
+}
+
+func (p *periodMetrics) GetJitter() time.Duration {
+	return 0
+}
+
+func (p *periodMetrics) GetPercentileRTT(q float64) time.Duration {
+	return 0
+}
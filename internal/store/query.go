@@ -0,0 +1,102 @@
+package store
+
+import (
+	"sort"
+	"time"
+)
+
+// Summary aggregates the stored results of one target over a time range
+type Summary struct {
+	Key        string
+	Start      time.Time
+	End        time.Time
+	Successful int
+	Failed     int
+	MinimumRTT time.Duration
+	MaximumRTT time.Duration
+	TotalRTT   time.Duration
+}
+
+func (s Summary) Total() int {
+	return s.Successful + s.Failed
+}
+
+// Loss returns the loss rate in percent, or 0 without results
+func (s Summary) Loss() float64 {
+	if s.Total() == 0 {
+		return 0
+	}
+	return float64(s.Failed) / float64(s.Total()) * 100
+}
+
+func (s Summary) AverageRTT() time.Duration {
+	if s.Successful == 0 {
+		return 0
+	}
+	return s.TotalRTT / time.Duration(s.Successful)
+}
+
+func (s *Summary) add(r Result) {
+	if !r.Success {
+		s.Failed++
+		return
+	}
+	s.Successful++
+	s.TotalRTT += r.RTT
+	if s.MinimumRTT == 0 || r.RTT < s.MinimumRTT {
+		s.MinimumRTT = r.RTT
+	}
+	if r.RTT > s.MaximumRTT {
+		s.MaximumRTT = r.RTT
+	}
+}
+
+// Summarize aggregates the results in [from, to) per target, and per step if
+// step is positive. Only the given keys are included unless keys is empty.
+// Summaries are sorted by key and then by time; ranges without results are omitted.
+func Summarize(src Source, from, to time.Time, step time.Duration, keys []string) ([]Summary, error) {
+	wanted := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		wanted[k] = true
+	}
+	type slot struct {
+		key   string
+		start int64
+	}
+	sums := make(map[slot]*Summary)
+	err := src.Scan(from, to, func(r Result) {
+		if len(wanted) > 0 && !wanted[r.Key] {
+			return
+		}
+		start, end := from, to
+		if step > 0 {
+			start = from.Add(r.Time.Sub(from) / step * step)
+			end = start.Add(step)
+			if end.After(to) {
+				end = to
+			}
+		}
+		k := slot{r.Key, start.UnixNano()}
+		s, ok := sums[k]
+		if !ok {
+			s = &Summary{Key: r.Key, Start: start, End: end}
+			sums[k] = s
+		}
+		s.add(r)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]Summary, 0, len(sums))
+	for _, s := range sums {
+		res = append(res, *s)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Key != res[j].Key {
+			return res[i].Key < res[j].Key
+		}
+		return res[i].Start.Before(res[j].Start)
+	})
+	return res, nil
+}
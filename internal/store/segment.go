@@ -0,0 +1,190 @@
+package store
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	segmentDuration = time.Hour
+	segmentLayout   = "2006010215" // UTC hour a segment starts at
+	segmentPrefix   = "results-"
+	segmentSuffix   = ".log"
+)
+
+// SegmentLog stores results in a directory of append-only files, one per hour.
+// Each file holds one JSON result per line, so a line torn by a crash only
+// loses that result, and old hours can be removed by deleting their files.
+type SegmentLog struct {
+	dir      string
+	readOnly bool
+	mu       sync.Mutex
+	// The newest segment and the one before it stay open: results arrive
+	// slightly out of order (a timeout carries the older send time of its
+	// probe), and would otherwise reopen the files at every hour boundary.
+	cur, prev segment
+}
+
+// segment is an open segment file
+type segment struct {
+	f     *os.File
+	start time.Time // Start of the hour the segment holds
+}
+
+func (s *segment) close() error {
+	if s.f == nil {
+		return nil
+	}
+	err := s.f.Close()
+	*s = segment{}
+	if err != nil {
+		return fmt.Errorf("failed to close segment: %w", err)
+	}
+	return nil
+}
+
+// OpenSegmentLog opens the segment log in dir, creating dir if needed
+func OpenSegmentLog(dir string) (*SegmentLog, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create store: %w", err)
+	}
+	return &SegmentLog{dir: dir}, nil
+}
+
+// OpenSegmentLogReadOnly opens the existing segment log in dir for Scan only
+func OpenSegmentLogReadOnly(dir string) (*SegmentLog, error) {
+	fi, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open store: %w", err)
+	}
+	if !fi.IsDir() {
+		return nil, fmt.Errorf("failed to open store: %s is not a directory", dir)
+	}
+	return &SegmentLog{dir: dir, readOnly: true}, nil
+}
+
+// Write appends r to the segment of the hour it was sent in
+func (l *SegmentLog) Write(r Result) error {
+	if l.readOnly {
+		return fmt.Errorf("store %s is open read-only", l.dir)
+	}
+	line, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to encode result: %w", err)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	start := r.Time.UTC().Truncate(segmentDuration)
+	var seg *segment
+	switch {
+	case l.cur.f != nil && start.Equal(l.cur.start):
+		seg = &l.cur
+	case l.prev.f != nil && start.Equal(l.prev.start):
+		seg = &l.prev
+	case l.cur.f == nil || start.After(l.cur.start):
+		// A new hour: the current segment becomes the previous one
+		if err := l.prev.close(); err != nil {
+			return err
+		}
+		f, err := l.openSegment(start)
+		if err != nil {
+			return err
+		}
+		l.prev, l.cur = l.cur, segment{f: f, start: start}
+		seg = &l.cur
+	default:
+		// Older than both open segments, which is rare: append without keeping it open
+		f, err := l.openSegment(start)
+		if err != nil {
+			return err
+		}
+		old := segment{f: f, start: start}
+		if _, err := f.Write(append(line, '\n')); err != nil {
+			old.close()
+			return fmt.Errorf("failed to write result: %w", err)
+		}
+		return old.close()
+	}
+	if _, err := seg.f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write result: %w", err)
+	}
+	return nil
+}
+
+func (l *SegmentLog) openSegment(start time.Time) (*os.File, error) {
+	path := filepath.Join(l.dir, segmentPrefix+start.Format(segmentLayout)+segmentSuffix)
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open segment: %w", err)
+	}
+	return f, nil
+}
+
+func (l *SegmentLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	err := l.cur.close()
+	if perr := l.prev.close(); err == nil {
+		err = perr
+	}
+	return err
+}
+
+// Scan reads the segments overlapping [from, to) in order. Lines that cannot
+// be parsed are skipped.
+func (l *SegmentLog) Scan(from, to time.Time, fn func(Result)) error {
+	entries, err := os.ReadDir(l.dir)
+	if err != nil {
+		return fmt.Errorf("failed to read store: %w", err)
+	}
+	var names []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, segmentPrefix) || !strings.HasSuffix(name, segmentSuffix) {
+			continue
+		}
+		start, err := time.Parse(segmentLayout, strings.TrimSuffix(strings.TrimPrefix(name, segmentPrefix), segmentSuffix))
+		if err != nil || !start.Before(to) || !start.Add(segmentDuration).After(from) {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := scanSegment(filepath.Join(l.dir, name), from, to, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func scanSegment(path string, from, to time.Time, fn func(Result)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open segment: %w", err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var r Result
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			continue
+		}
+		if !r.Time.Before(from) && r.Time.Before(to) {
+			fn(r)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read segment %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+// Package store keeps probe results in local files so that they can be
+// queried after mping has exited.
+package store
+
+import (
+	"sync"
+	"time"
+
+	"github.com/servak/mping/internal/prober"
+)
+
+// Result is one stored probe result
+type Result struct {
+	Time    time.Time     `json:"t"` // When the probe was sent
+	Key     string        `json:"key"`
+	Success bool          `json:"ok"`
+	RTT     time.Duration `json:"rtt,omitempty"`
+	Error   string        `json:"err,omitempty"`
+}
+
+// Sink is a storage backend for probe results
+type Sink interface {
+	Write(r Result) error
+	Close() error
+}
+
+// Source reads stored probe results back
+type Source interface {
+	// Scan calls fn for every result sent in [from, to)
+	Scan(from, to time.Time, fn func(Result)) error
+}
+
+// Writer stores the results among probe events in a Sink
+type Writer struct {
+	mu     sync.Mutex
+	sink   Sink
+	err    error
+	closed bool
+}
+
+func NewWriter(sink Sink) *Writer {
+	return &Writer{sink: sink}
+}
+
+// WriteAll stores the results among the events of in until in is closed
+func (w *Writer) WriteAll(in <-chan *prober.Event) {
+	for e := range in {
+		w.Write(e)
+	}
+}
+
+// Write stores e if it is a probe result. After the first error or Close
+// nothing more is written.
+func (w *Writer) Write(e *prober.Event) {
+	r, ok := resultOf(e)
+	if !ok {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil || w.closed {
+		return
+	}
+	w.err = w.sink.Write(r)
+}
+
+// Close closes the sink and returns the first error, if any
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return w.err
+	}
+	w.closed = true
+	if err := w.sink.Close(); w.err == nil {
+		w.err = err
+	}
+	return w.err
+}
+
+func resultOf(e *prober.Event) (Result, bool) {
+	r := Result{Time: e.SentTime, Key: e.Key}
+	if r.Time.IsZero() {
+		r.Time = time.Now()
+	}
+	switch e.Result {
+	case prober.SUCCESS:
+		r.Success = true
+		r.RTT = e.Rtt
+	case prober.TIMEOUT:
+		r.Error = e.Message
+		if r.Error == "" {
+			r.Error = "timeout"
+		}
+	case prober.FAILED:
+		r.Error = e.Message
+	default:
+		return Result{}, false
+	}
+	return r, true
+}
@@ -0,0 +1,171 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/servak/mping/internal/prober"
+)
+
+func TestWriterAndSegmentLog(t *testing.T) {
+	dir := t.TempDir()
+	log, err := OpenSegmentLog(dir)
+	if err != nil {
+		t.Fatalf("OpenSegmentLog() error = %v", err)
+	}
+	w := NewWriter(log)
+
+	base := time.Date(2026, 10, 18, 9, 59, 0, 0, time.UTC)
+	in := make(chan *prober.Event, 10)
+	in <- &prober.Event{Key: "a", Result: prober.REGISTER}
+	in <- &prober.Event{Key: "a", Result: prober.SENT, SentTime: base}
+	in <- &prober.Event{Key: "a", Result: prober.SUCCESS, SentTime: base, Rtt: 10 * time.Millisecond}
+	in <- &prober.Event{Key: "a", Result: prober.SUCCESS, SentTime: base.Add(2 * time.Minute), Rtt: 30 * time.Millisecond}
+	in <- &prober.Event{Key: "a", Result: prober.TIMEOUT, SentTime: base.Add(time.Minute)}
+	in <- &prober.Event{Key: "b", Result: prober.FAILED, SentTime: base.Add(time.Minute), Message: "connection refused"}
+	close(in)
+	w.WriteAll(in)
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	w.Write(&prober.Event{Key: "a", Result: prober.SUCCESS, SentTime: base})
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2 {
+		t.Fatalf("Expected one segment per hour, got %d files", len(entries))
+	}
+	// A line torn by a crash is skipped
+	f, _ := os.OpenFile(filepath.Join(dir, "results-2026101810.log"), os.O_WRONLY|os.O_APPEND, 0)
+	f.WriteString(`{"t":"2026-10-18T10:0`)
+	f.Close()
+
+	var results []Result
+	err = log.Scan(base, base.Add(time.Hour), func(r Result) {
+		results = append(results, r)
+	})
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if len(results) != 4 {
+		t.Fatalf("Expected 4 results, got %+v", results)
+	}
+	if !results[0].Success || results[0].RTT != 10*time.Millisecond {
+		t.Errorf("Unexpected first result: %+v", results[0])
+	}
+	if r := results[len(results)-1]; r.Key != "b" || r.Success || r.Error != "connection refused" {
+		t.Errorf("Unexpected last result: %+v", r)
+	}
+	for _, r := range results {
+		if r.Key == "a" && !r.Success && r.Error != "timeout" {
+			t.Errorf("Expected timeouts to be stored with an error, got %+v", r)
+		}
+	}
+
+	results = nil
+	log.Scan(base.Add(time.Minute), base.Add(2*time.Minute), func(r Result) {
+		results = append(results, r)
+	})
+	if len(results) != 2 {
+		t.Errorf("Expected the range to be filtered, got %+v", results)
+	}
+}
+
+func TestSegmentLogHourBoundary(t *testing.T) {
+	dir := t.TempDir()
+	log, err := OpenSegmentLog(dir)
+	if err != nil {
+		t.Fatalf("OpenSegmentLog() error = %v", err)
+	}
+	base := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	// Timeouts carry the older send time of their probe
+	for _, at := range []time.Duration{-time.Second, time.Second, -2 * time.Second, 2 * time.Second} {
+		if err := log.Write(Result{Time: base.Add(at), Key: "a"}); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	previous, current := log.prev.f, log.cur.f
+	if previous == nil || !log.prev.start.Equal(base.Add(-time.Hour)) || !log.cur.start.Equal(base) {
+		t.Fatalf("Expected both hours to stay open, got %v and %v", log.prev.start, log.cur.start)
+	}
+	// A result older than both open hours does not replace them
+	if err := log.Write(Result{Time: base.Add(-3 * time.Hour), Key: "a"}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if log.prev.f != previous || log.cur.f != current {
+		t.Error("Expected the open segments to be kept")
+	}
+	if err := log.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	var n int
+	log.Scan(base.Add(-3*time.Hour), base.Add(time.Hour), func(Result) { n++ })
+	if entries, _ := os.ReadDir(dir); len(entries) != 3 || n != 5 {
+		t.Errorf("Expected 5 results in 3 segments, got %d in %d", n, len(entries))
+	}
+}
+
+func TestOpenSegmentLogReadOnly(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing")
+	if _, err := OpenSegmentLogReadOnly(missing); err == nil {
+		t.Error("Expected an error for a missing store")
+	}
+	if _, err := os.Stat(missing); !os.IsNotExist(err) {
+		t.Error("Expected a missing store not to be created")
+	}
+
+	log, err := OpenSegmentLogReadOnly(t.TempDir())
+	if err != nil {
+		t.Fatalf("OpenSegmentLogReadOnly() error = %v", err)
+	}
+	if err := log.Write(Result{Time: time.Now(), Key: "a"}); err == nil {
+		t.Error("Expected Write() to fail on a read-only store")
+	}
+}
+
+type results []Result
+
+func (rs results) Scan(from, to time.Time, fn func(Result)) error {
+	for _, r := range rs {
+		if !r.Time.Before(from) && r.Time.Before(to) {
+			fn(r)
+		}
+	}
+	return nil
+}
+
+func TestSummarize(t *testing.T) {
+	base := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	src := results{
+		{Time: base, Key: "a", Success: true, RTT: 10 * time.Millisecond},
+		{Time: base.Add(10 * time.Minute), Key: "a", Success: true, RTT: 30 * time.Millisecond},
+		{Time: base.Add(20 * time.Minute), Key: "a"},
+		{Time: base.Add(70 * time.Minute), Key: "a"},
+		{Time: base.Add(5 * time.Minute), Key: "b", Success: true, RTT: 5 * time.Millisecond},
+	}
+
+	sums, err := Summarize(src, base, base.Add(2*time.Hour), 0, nil)
+	if err != nil {
+		t.Fatalf("Summarize() error = %v", err)
+	}
+	if len(sums) != 2 || sums[0].Key != "a" || sums[1].Key != "b" {
+		t.Fatalf("Expected one summary per target, got %+v", sums)
+	}
+	a := sums[0]
+	if a.Total() != 4 || a.Loss() != 50 || a.AverageRTT() != 20*time.Millisecond || a.MinimumRTT != 10*time.Millisecond || a.MaximumRTT != 30*time.Millisecond {
+		t.Errorf("Unexpected summary: %+v", a)
+	}
+
+	sums, _ = Summarize(src, base, base.Add(90*time.Minute), time.Hour, []string{"a"})
+	if len(sums) != 2 {
+		t.Fatalf("Expected two hourly summaries of a, got %+v", sums)
+	}
+	if sums[0].Total() != 3 || !sums[0].End.Equal(base.Add(time.Hour)) {
+		t.Errorf("Unexpected first period: %+v", sums[0])
+	}
+	if sums[1].Failed != 1 || !sums[1].Start.Equal(base.Add(time.Hour)) || !sums[1].End.Equal(base.Add(90*time.Minute)) {
+		t.Errorf("Expected the last period to end with the range, got %+v", sums[1])
+	}
+}
@@ -9,17 +9,19 @@ import (
 
 // Config manages UI settings
 type Config struct {
-	Title  string           `yaml:"-"`
-	Theme  string           `yaml:"theme"`  // "light", "dark", "custom"
-	Themes map[string]Theme `yaml:"themes"` // User-defined themes
+	Title         string           `yaml:"-"`
+	Theme         string           `yaml:"theme"`          // "light", "dark", "custom"
+	Themes        map[string]Theme `yaml:"themes"`         // User-defined themes
+	SpreadColumns bool             `yaml:"spread_columns"` // Show Jitter, StdDev and the percentiles in the table
 }
 
 // UnmarshalYAML implements yaml.Unmarshaler to handle theme merging
 func (c *Config) UnmarshalYAML(value *yaml.Node) error {
 	// Create a temporary structure to unmarshal into
 	type tempConfig struct {
-		Theme  string           `yaml:"theme"`
-		Themes map[string]Theme `yaml:"themes"`
+		Theme         string           `yaml:"theme"`
+		Themes        map[string]Theme `yaml:"themes"`
+		SpreadColumns *bool            `yaml:"spread_columns"`
 	}
 
 	var temp tempConfig
@@ -32,6 +34,10 @@ func (c *Config) UnmarshalYAML(value *yaml.Node) error {
 		c.Theme = temp.Theme
 	}
 
+	if temp.SpreadColumns != nil {
+		c.SpreadColumns = *temp.SpreadColumns
+	}
+
 	// Merge themes if provided
 	if temp.Themes != nil {
 		if err := c.mergeThemes(temp.Themes); err != nil {
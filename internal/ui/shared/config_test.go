@@ -237,3 +237,20 @@ func TestGetAllThemeList(t *testing.T) {
 		}
 	}
 }
+
+func TestSpreadColumnsConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.SpreadColumns {
+		t.Error("Expected the spread columns to be hidden by default")
+	}
+
+	if err := yaml.Unmarshal([]byte("spread_columns: true\n"), cfg); err != nil {
+		t.Fatalf("Failed to unmarshal YAML: %v", err)
+	}
+	if !cfg.SpreadColumns {
+		t.Error("Expected spread_columns: true to show the spread columns")
+	}
+	if cfg.Theme != "dark" {
+		t.Errorf("Expected theme to stay 'dark', got '%s'", cfg.Theme)
+	}
+}
@@ -2,6 +2,7 @@ package shared
 
 import (
 	"strings"
+	"time"
 
 	"github.com/servak/mping/internal/stats"
 )
@@ -21,3 +22,12 @@ func FilterMetrics(metrics []stats.Metrics, filterText string) []stats.Metrics {
 	}
 	return filtered
 }
+
+// WindowMetrics switches metrics to their statistics over the last window and
+// sorts them again by k. A zero window keeps the lifetime statistics.
+func WindowMetrics(metrics []stats.Metrics, window time.Duration, k stats.Key, ascending bool) []stats.Metrics {
+	if window == 0 {
+		return metrics
+	}
+	return stats.SortMetrics(stats.InPeriod(metrics, window), k, ascending)
+}
@@ -5,6 +5,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/rivo/tview"
+
 	"github.com/servak/mping/internal/prober"
 	"github.com/servak/mping/internal/stats"
 )
@@ -21,6 +23,19 @@ func DurationFormater(duration time.Duration) string {
 	}
 }
 
+// DeltaFormater formats a signed RTT difference (e.g. "+3ms", "-120µs")
+func DeltaFormater(delta time.Duration) string {
+	if delta == 0 {
+		return "-"
+	}
+	sign := "+"
+	if delta < 0 {
+		sign = "-"
+		delta = -delta
+	}
+	return sign + strings.TrimSpace(DurationFormater(delta))
+}
+
 func TimeFormater(t time.Time) string {
 	if t.IsZero() {
 		return "-"
@@ -57,6 +72,9 @@ func FormatHostDetail(metric stats.Metrics, theme *Theme) string {
 [%s]Average RTT:[%s] %s
 [%s]Minimum RTT:[%s] %s
 [%s]Maximum RTT:[%s] %s
+[%s]Jitter:[%s] %s
+[%s]Std Deviation:[%s] %s
+[%s]Percentiles:[%s] p50 %s  p90 %s  p95 %s  p99 %s
 [%s]Last Success:[%s] %s
 [%s]Last Failure:[%s] %s
 [%s]Last Error:[%s] %s`,
@@ -68,11 +86,50 @@ func FormatHostDetail(metric stats.Metrics, theme *Theme) string {
 		theme.Accent, theme.Primary, DurationFormater(metric.GetAverageRTT()),
 		theme.Accent, theme.Primary, DurationFormater(metric.GetMinimumRTT()),
 		theme.Accent, theme.Primary, DurationFormater(metric.GetMaximumRTT()),
+		theme.Accent, theme.Primary, DurationFormater(metric.GetJitter()),
+		theme.Accent, theme.Primary, DurationFormater(metric.GetStdDev()),
+		theme.Accent, theme.Primary,
+		strings.TrimSpace(DurationFormater(metric.GetPercentileRTT(0.50))),
+		strings.TrimSpace(DurationFormater(metric.GetPercentileRTT(0.90))),
+		strings.TrimSpace(DurationFormater(metric.GetPercentileRTT(0.95))),
+		strings.TrimSpace(DurationFormater(metric.GetPercentileRTT(0.99))),
 		theme.Accent, theme.Primary, TimeFormater(metric.GetLastSuccTime()),
 		theme.Accent, theme.Primary, TimeFormater(metric.GetLastFailTime()),
 		theme.Accent, theme.Primary, metric.GetLastFailDetail(),
 	)
 
+	// Reply anomalies; only ICMP tracks these, so skip the section when there are none
+	if metric.GetDuplicates() > 0 || metric.GetReordered() > 0 || metric.GetLate() > 0 {
+		basicInfo += fmt.Sprintf(`
+[%s]Duplicates:[%s] %d
+[%s]Reordered:[%s] %d
+[%s]Late Replies:[%s] %d`,
+			theme.Accent, theme.Primary, metric.GetDuplicates(),
+			theme.Accent, theme.Primary, metric.GetReordered(),
+			theme.Accent, theme.Primary, metric.GetLate(),
+		)
+	}
+
+	basicInfo += "\n\n" + formatRecentStats(metric, theme)
+
+	if metric.GetOutages() > 0 {
+		basicInfo += "\n\n" + formatIncidents(metric, theme)
+	}
+
+	if c, ok := metric.(stats.Comparison); ok {
+		basicInfo += fmt.Sprintf(`
+
+[%s]IPv6 vs IPv4:[%s]
+[%s]Loss Delta:[%s] %+.1f%%
+[%s]Last RTT Delta:[%s] %s
+[%s]Average RTT Delta:[%s] %s`,
+			theme.Warning, theme.Primary,
+			theme.Accent, theme.Primary, c.GetLossDelta(),
+			theme.Accent, theme.Primary, DeltaFormater(c.GetLastRTTDelta()),
+			theme.Accent, theme.Primary, DeltaFormater(c.GetAverageRTTDelta()),
+		)
+	}
+
 	// Add history section
 	historySection := FormatHistory(metric, theme)
 	if historySection != "" {
@@ -82,6 +139,82 @@ func FormatHostDetail(metric stats.Metrics, theme *Theme) string {
 	return basicInfo
 }
 
+// formatRecentStats shows loss, success rate and average RTT over each of stats.StatsWindows
+func formatRecentStats(metric stats.Metrics, theme *Theme) string {
+	windows := make([]string, len(stats.StatsWindows))
+	loss := make([]string, len(stats.StatsWindows))
+	rate := make([]string, len(stats.StatsWindows))
+	avg := make([]string, len(stats.StatsWindows))
+	for i, d := range stats.StatsWindows {
+		windows[i] = WindowFormater(d)
+		loss[i], rate[i] = "-", "-"
+		if metric.GetSuccessfulInPeriod(d)+metric.GetFailedInPeriod(d) > 0 {
+			l := metric.GetLossInPeriod(d)
+			loss[i] = fmt.Sprintf("%.1f%%", l)
+			rate[i] = fmt.Sprintf("%.1f%%", 100-l)
+		}
+		avg[i] = strings.TrimSpace(DurationFormater(metric.GetAverageRTTInPeriod(d)))
+	}
+	return fmt.Sprintf(`[%s]Recent (%s):[%s]
+[%s]Loss Rate:[%s] %s
+[%s]Success Rate:[%s] %s
+[%s]Average RTT:[%s] %s`,
+		theme.Warning, strings.Join(windows, " / "), theme.Primary,
+		theme.Accent, theme.Primary, strings.Join(loss, " / "),
+		theme.Accent, theme.Primary, strings.Join(rate, " / "),
+		theme.Accent, theme.Primary, strings.Join(avg, " / "),
+	)
+}
+
+// maxDetailIncidents is the number of incidents listed in the detail panel
+const maxDetailIncidents = 5
+
+// formatIncidents summarizes the outages and lists the most recent ones
+func formatIncidents(metric stats.Metrics, theme *Theme) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%s]Outages:[%s] %d  [%s]Availability:[%s] %.2f%%  [%s]MTTR:[%s] %s",
+		theme.Warning, theme.Primary, metric.GetOutages(),
+		theme.Accent, theme.Primary, metric.GetAvailability(),
+		theme.Accent, theme.Primary, ElapsedFormater(metric.GetMTTR()),
+	)
+	for i, incident := range metric.GetIncidents() {
+		if i == maxDetailIncidents {
+			break
+		}
+		end, color := TimeFormater(incident.End), theme.Primary
+		if incident.Ongoing() {
+			end, color = "ongoing", theme.Error
+		}
+		fmt.Fprintf(&b, "\n  [%s]%s - %-8s %8s[%s]  %s",
+			color, TimeFormater(incident.Start), end, ElapsedFormater(incident.Duration()), theme.Primary,
+			tview.Escape(incident.FirstError),
+		)
+	}
+	return b.String()
+}
+
+// ElapsedFormater formats a period such as an outage (e.g. "2m5s"), or "-" for 0
+func ElapsedFormater(d time.Duration) string {
+	if d <= 0 {
+		return "-"
+	}
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(time.Second).String()
+}
+
+// WindowFormater formats a stats window (e.g. "5m"), or "lifetime" for 0
+func WindowFormater(d time.Duration) string {
+	if d == 0 {
+		return "lifetime"
+	}
+	if d%time.Minute == 0 {
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	return d.String()
+}
+
 // FormatHistory generates history section for a host
 func FormatHistory(metric stats.Metrics, theme *Theme) string {
 	history := metric.GetRecentHistory(10)
@@ -95,22 +228,7 @@ func FormatHistory(metric stats.Metrics, theme *Theme) string {
 	sb.WriteString(fmt.Sprintf("[%s]-------- ------ ------- --------[%s]\n", theme.Separator, theme.Primary))
 
 	for _, entry := range history {
-		statusColor := theme.Success
-		status := "OK"
-		details := ""
-
-		if !entry.Success {
-			status = "FAIL"
-			statusColor = theme.Error
-			// Show error message for failed entries
-			if entry.Error != "" {
-				details = fmt.Sprintf("[%s]%s[%s]", theme.Error, entry.Error, theme.Primary)
-			}
-		} else {
-			// Show probe-specific details for successful entries
-			details = formatProbeDetails(entry.Details)
-		}
-
+		status, statusColor, details := HistoryEntryStatus(entry, theme)
 		sb.WriteString(fmt.Sprintf("[%s]%-8s[%s] [%s]%-6s[%s] %-7s %s\n",
 			theme.Timestamp, entry.Timestamp.Format("15:04:05"),
 			theme.Primary, statusColor, status,
@@ -144,6 +262,10 @@ func formatProbeDetails(details *prober.ProbeDetails) string {
 				parts = append(parts, fmt.Sprintf("payload=%s", details.ICMP.Payload))
 			}
 
+			if details.ICMP.From != "" {
+				parts = append(parts, fmt.Sprintf("code=%d from=%s", details.ICMP.ICMPCode, details.ICMP.From))
+			}
+
 			return strings.Join(parts, " ")
 		}
 		return "icmp ping"
@@ -183,6 +305,21 @@ func formatProbeDetails(details *prober.ProbeDetails) string {
 		}
 		return "ntp sync"
 	case "tcp":
+		if details.TCP != nil {
+			var parts []string
+			if details.TCP.FailureKind != "" {
+				parts = append(parts, fmt.Sprintf("kind=%s", details.TCP.FailureKind))
+			}
+			if details.TCP.LocalAddr != "" {
+				parts = append(parts, fmt.Sprintf("local=%s", details.TCP.LocalAddr))
+			}
+			if details.TCP.Banner != "" {
+				parts = append(parts, fmt.Sprintf("banner=%s", tview.Escape(details.TCP.Banner)))
+			}
+			if len(parts) > 0 {
+				return strings.Join(parts, " ")
+			}
+		}
 		return "connection"
 	}
 
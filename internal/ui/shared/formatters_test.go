@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/servak/mping/internal/prober"
 	"github.com/servak/mping/internal/stats"
 )
 
@@ -212,3 +213,101 @@ func findSubstring(s, substr string) bool {
 	}
 	return false
 }
+
+func TestFormatHostDetailRecent(t *testing.T) {
+	theme := &Theme{Primary: "#ffffff", Warning: "#ffff00", Accent: "#00afd7"}
+	metric := stats.NewMetrics("example.com", 10)
+	result := FormatHostDetail(metric, theme)
+	if !contains(result, "[#ffff00]Recent (1m / 5m / 15m):[#ffffff]") || !contains(result, "[#00afd7]Loss Rate:[#ffffff] - / - / -") {
+		t.Errorf("Expected empty recent statistics, got:\n%s", result)
+	}
+}
+
+func TestWindowFormater(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		0:                "lifetime",
+		time.Minute:      "1m",
+		15 * time.Minute: "15m",
+		90 * time.Second: "1m30s",
+	} {
+		if got := WindowFormater(d); got != want {
+			t.Errorf("WindowFormater(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
+
+func TestFormatIncidents(t *testing.T) {
+	mm := stats.NewMetricsManager()
+	mm.SetOutageThresholds(1, 1)
+	start := time.Date(2024, 1, 1, 15, 30, 0, 0, time.Local)
+	mm.Record(&prober.Event{Key: "192.0.2.1", Result: prober.FAILED, SentTime: start, Message: "host unreachable"})
+	mm.Record(&prober.Event{Key: "192.0.2.1", Result: prober.SUCCESS, SentTime: start.Add(90 * time.Second), Rtt: time.Millisecond})
+	mm.Record(&prober.Event{Key: "192.0.2.1", Result: prober.SUCCESS, SentTime: start.Add(180 * time.Second), Rtt: time.Millisecond})
+
+	ms := mm.SortBy(stats.Host, true)
+	if len(ms) != 1 || ms[0].GetSuccessful() != 2 {
+		t.Fatal("Expected events to be recorded")
+	}
+	metric := ms[0]
+
+	theme := &Theme{Primary: "#ffffff", Warning: "#ffff00", Accent: "#00afd7", Error: "#ff0000"}
+	result := FormatHostDetail(metric, theme)
+	for _, expected := range []string{
+		"[#ffff00]Outages:[#ffffff] 1",
+		"[#00afd7]Availability:[#ffffff] 50.00%",
+		"[#00afd7]MTTR:[#ffffff] 1m30s",
+		"15:30:00 - 15:31:30",
+		"host unreachable",
+	} {
+		if !contains(result, expected) {
+			t.Errorf("FormatHostDetail result missing %q:\n%s", expected, result)
+		}
+	}
+
+	row := NewOutageTableData([]stats.Metrics{metric}).Rows[0]
+	if row[1] != "1" || row[2] != "1m30s" || row[3] != "1m30s" || row[4] != "50.00%" || row[5] != "15:30:00" || row[6] != "host unreachable" {
+		t.Errorf("Unexpected outage row: %q", row)
+	}
+}
+
+func TestNewTableDataSpreadColumns(t *testing.T) {
+	mm := stats.NewMetricsManager()
+	mm.Register("example.com", "example.com")
+	metrics := mm.SortBy(stats.Host, true)
+
+	td := NewTableData(metrics, stats.Host, true, Columns{})
+	if len(td.Headers) != len(td.Rows[0]) {
+		t.Fatalf("Headers and row differ in length: %q %q", td.Headers, td.Rows[0])
+	}
+	for _, h := range td.Headers {
+		if h == "Jitter" || h == "StdDev" || h == "P99" {
+			t.Errorf("Unexpected spread column %q without spread", h)
+		}
+	}
+	if td.Headers[9] != "LastSuccTime" {
+		t.Errorf("Expected LastSuccTime after Worst, got %q", td.Headers[9])
+	}
+
+	td = NewTableData(metrics, stats.Host, true, Columns{Spread: true})
+	if len(td.Headers) != len(td.Rows[0]) || td.Headers[9] != "Jitter" || td.Headers[14] != "P99" {
+		t.Errorf("Expected the spread columns after Worst, got %q", td.Headers)
+	}
+
+	// A window has no jitter or percentiles, so only StdDev remains
+	td = NewTableData(metrics, stats.Host, true, Columns{Spread: true, Windowed: true})
+	if len(td.Headers) != len(td.Rows[0]) || td.Headers[9] != "StdDev" || td.Headers[10] != "LastSuccTime" {
+		t.Errorf("Expected only StdDev after Worst in a window, got %q", td.Headers)
+	}
+}
+
+func TestElapsedFormater(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		0:                                     "-",
+		1500 * time.Microsecond:               "2ms",
+		90*time.Second + 400*time.Millisecond: "1m30s",
+	} {
+		if got := ElapsedFormater(d); got != want {
+			t.Errorf("ElapsedFormater(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
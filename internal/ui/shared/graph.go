@@ -0,0 +1,214 @@
+package shared
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/servak/mping/internal/stats"
+)
+
+// GraphPoint is one sample of an RTT graph: a probe result or a rollup
+type GraphPoint struct {
+	Time       time.Time
+	RTT        time.Duration // Average RTT of the successful probes, 0 without any
+	Successful int
+	Failed     int
+}
+
+// Loss returns the loss of the sample in percent, or 0 without probes
+func (p GraphPoint) Loss() float64 {
+	if p.Successful+p.Failed == 0 {
+		return 0
+	}
+	return float64(p.Failed) / float64(p.Successful+p.Failed) * 100
+}
+
+// GraphRange is a period the RTT graph can be zoomed to
+type GraphRange struct {
+	Name       string
+	Resolution time.Duration // Rollup resolution, or 0 for the per-probe history
+	Count      int           // Number of history entries or rollups
+}
+
+// GraphRanges are the zoom levels of the RTT graph, shortest first
+var GraphRanges = []GraphRange{
+	{Name: "recent probes", Count: stats.DefaultHistorySize},
+	{Name: "1 hour", Resolution: time.Minute, Count: 60},
+	{Name: "6 hours", Resolution: time.Minute, Count: 6 * 60},
+	{Name: "24 hours", Resolution: time.Hour, Count: 24},
+	{Name: "7 days", Resolution: time.Hour, Count: 7 * 24},
+}
+
+// GraphPoints returns the samples of metric over r, oldest first
+func GraphPoints(metric stats.Metrics, r GraphRange) []GraphPoint {
+	if r.Resolution == 0 {
+		results := ProbeResults(metric.GetRecentHistory(r.Count))
+		points := make([]GraphPoint, len(results))
+		for i, e := range results {
+			p := &points[len(results)-1-i]
+			p.Time = e.Timestamp
+			if e.Success {
+				p.RTT, p.Successful = e.RTT, 1
+			} else {
+				p.Failed = 1
+			}
+		}
+		return points
+	}
+
+	rollups := metric.GetRollups(r.Resolution, r.Count)
+	// Skip the periods before the first probe
+	for len(rollups) > 0 && rollups[0].Total() == 0 {
+		rollups = rollups[1:]
+	}
+	points := make([]GraphPoint, len(rollups))
+	for i, r := range rollups {
+		points[i] = GraphPoint{Time: r.Start, RTT: r.AverageRTT, Successful: r.Successful, Failed: r.Failed}
+	}
+	return points
+}
+
+// graphAxisWidth is the width of the RTT labels left of the chart
+const graphAxisWidth = 8
+
+// braille dot bits by column and row within a cell
+var brailleDots = [2][4]rune{
+	{0x01, 0x02, 0x04, 0x40},
+	{0x08, 0x10, 0x20, 0x80},
+}
+
+// RenderGraph draws points as a braille line chart of RTT over time in width
+// by height cells. Below the chart a row marks samples with loss (• partial,
+// × total) and a row shows the time range. Points are merged when there are
+// more than the chart can show.
+func RenderGraph(points []GraphPoint, width, height int, theme *Theme) string {
+	cols, rows := width-graphAxisWidth, height-2
+	if cols < 2 || rows < 1 {
+		return ""
+	}
+	if len(points) == 0 {
+		return fmt.Sprintf("[%s]No results in this range[%s]", theme.Secondary, theme.Primary)
+	}
+	points = mergePoints(points, cols*2)
+
+	var top time.Duration
+	for _, p := range points {
+		top = max(top, p.RTT)
+	}
+
+	// Plot the RTTs on a grid of braille dots, 2 wide and 4 high per cell
+	w, h := cols*2, rows*4
+	cells := make([][]rune, rows)
+	for i := range cells {
+		cells[i] = make([]rune, cols)
+	}
+	set := func(x, y int) {
+		cells[y/4][x/2] |= brailleDots[x%2][y%4]
+	}
+	xOf := func(i int) int {
+		if len(points) == 1 {
+			return 0
+		}
+		return i * (w - 1) / (len(points) - 1)
+	}
+	yOf := func(rtt time.Duration) int {
+		if top == 0 {
+			return h - 1
+		}
+		return h - 1 - int(int64(rtt)*int64(h-1)/int64(top))
+	}
+	prev := -1
+	for i, p := range points {
+		if p.Successful == 0 {
+			prev = -1 // Break the line at samples without replies
+			continue
+		}
+		x1, y1 := xOf(i), yOf(p.RTT)
+		x0, y0 := x1, y1
+		if prev >= 0 {
+			x0, y0 = xOf(prev), yOf(points[prev].RTT)
+		}
+		steps := max(abs(x1-x0), abs(y1-y0), 1)
+		for s := 0; s <= steps; s++ {
+			set(x0+(x1-x0)*s/steps, y0+(y1-y0)*s/steps)
+		}
+		prev = i
+	}
+
+	// Combine the loss of the samples in each cell
+	failed, total := make([]int, cols), make([]int, cols)
+	for i, p := range points {
+		c := xOf(i) / 2
+		failed[c] += p.Failed
+		total[c] += p.Successful + p.Failed
+	}
+
+	var b strings.Builder
+	for r, row := range cells {
+		label := ""
+		switch r {
+		case 0:
+			label = strings.TrimSpace(DurationFormater(top))
+		case rows - 1:
+			label = "0"
+		}
+		fmt.Fprintf(&b, "[%s]%6s │[%s]", theme.Secondary, label, theme.Accent)
+		for _, c := range row {
+			b.WriteRune(0x2800 + c)
+		}
+		b.WriteString("[" + theme.Primary + "]\n")
+	}
+
+	fmt.Fprintf(&b, "[%s]%6s └", theme.Secondary, "loss")
+	for c := range cols {
+		switch {
+		case failed[c] == 0:
+			b.WriteString("─")
+		case failed[c] == total[c]:
+			fmt.Fprintf(&b, "[%s]×[%s]", theme.Error, theme.Secondary)
+		default:
+			fmt.Fprintf(&b, "[%s]•[%s]", theme.Warning, theme.Secondary)
+		}
+	}
+	b.WriteString("[" + theme.Primary + "]\n")
+
+	layout := "15:04:05"
+	first, last := points[0].Time, points[len(points)-1].Time
+	if last.Sub(first) >= 24*time.Hour {
+		layout = "01-02 15:04"
+	}
+	from, to := first.Format(layout), last.Format(layout)
+	fmt.Fprintf(&b, "[%s]%s%s%*s[%s]", theme.Timestamp, strings.Repeat(" ", graphAxisWidth), from, max(cols-len(from), 0), to, theme.Primary)
+	return b.String()
+}
+
+// mergePoints combines consecutive points so that at most n remain
+func mergePoints(points []GraphPoint, n int) []GraphPoint {
+	if len(points) <= n {
+		return points
+	}
+	res := make([]GraphPoint, n)
+	for i := range res {
+		from, to := i*len(points)/n, (i+1)*len(points)/n
+		p := GraphPoint{Time: points[from].Time}
+		var total time.Duration
+		for _, q := range points[from:to] {
+			total += q.RTT * time.Duration(q.Successful)
+			p.Successful += q.Successful
+			p.Failed += q.Failed
+		}
+		if p.Successful > 0 {
+			p.RTT = total / time.Duration(p.Successful)
+		}
+		res[i] = p
+	}
+	return res
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,104 @@
+package shared
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/servak/mping/internal/prober"
+	"github.com/servak/mping/internal/stats"
+)
+
+func TestGraphPoints(t *testing.T) {
+	mm := stats.NewMetricsManager()
+	now := time.Now()
+	mm.Register("192.0.2.1", "192.0.2.1")
+	mm.Record(&prober.Event{Key: "192.0.2.1", Result: prober.SUCCESS, SentTime: now, Rtt: 10 * time.Millisecond})
+	mm.Record(&prober.Event{Key: "192.0.2.1", Result: prober.TIMEOUT, SentTime: now.Add(time.Millisecond), Message: "timeout"})
+	mm.Record(&prober.Event{Key: "192.0.2.1", Result: prober.DUPLICATE, SentTime: now, Rtt: 12 * time.Millisecond})
+	mm.Record(&prober.Event{Key: "192.0.2.1", Result: prober.SUCCESS, SentTime: now.Add(2 * time.Millisecond), Rtt: 30 * time.Millisecond})
+
+	ms := mm.SortBy(stats.Host, true)
+	if len(ms) != 1 || ms[0].GetSuccessful() != 2 {
+		t.Fatal("Expected events to be recorded")
+	}
+	metric := ms[0]
+
+	points := GraphPoints(metric, GraphRanges[0])
+	if len(points) != 3 {
+		t.Fatalf("Expected 3 probe results, got %+v", points)
+	}
+	if points[0].RTT != 10*time.Millisecond || points[1].Failed != 1 || points[2].RTT != 30*time.Millisecond {
+		t.Errorf("Expected the results oldest first, got %+v", points)
+	}
+
+	points = GraphPoints(metric, GraphRanges[1])
+	if len(points) == 0 {
+		t.Fatal("Expected the current minute")
+	}
+	last := points[len(points)-1]
+	if last.Successful != 2 || last.Failed != 1 || last.RTT != 20*time.Millisecond {
+		t.Errorf("Unexpected rollup point %+v", last)
+	}
+	if points[0].Successful+points[0].Failed == 0 {
+		t.Error("Expected empty periods before the first probe to be skipped")
+	}
+}
+
+func TestMergePoints(t *testing.T) {
+	points := []GraphPoint{
+		{RTT: 10 * time.Millisecond, Successful: 1},
+		{RTT: 40 * time.Millisecond, Successful: 3},
+		{Failed: 2},
+		{RTT: 5 * time.Millisecond, Successful: 1, Failed: 1},
+	}
+	if got := mergePoints(points, 4); len(got) != 4 {
+		t.Errorf("Expected points to be kept, got %d", len(got))
+	}
+	got := mergePoints(points, 2)
+	if len(got) != 2 {
+		t.Fatalf("Expected 2 points, got %d", len(got))
+	}
+	if got[0].RTT != 32500*time.Microsecond || got[0].Successful != 4 || got[0].Failed != 0 {
+		t.Errorf("Unexpected first point %+v", got[0])
+	}
+	if got[1].RTT != 5*time.Millisecond || got[1].Failed != 3 || got[1].Loss() != 75 {
+		t.Errorf("Unexpected second point %+v", got[1])
+	}
+}
+
+func TestRenderGraph(t *testing.T) {
+	theme := &Theme{Primary: "white", Secondary: "gray", Accent: "blue", Warning: "yellow", Error: "red", Timestamp: "green"}
+	start := time.Date(2024, 1, 1, 15, 30, 0, 0, time.Local)
+	points := []GraphPoint{
+		{Time: start, RTT: 10 * time.Millisecond, Successful: 1},
+		{Time: start.Add(time.Second), Failed: 1},
+		{Time: start.Add(2 * time.Second), RTT: 20 * time.Millisecond, Successful: 1, Failed: 1},
+		{Time: start.Add(3 * time.Second), RTT: 30 * time.Millisecond, Successful: 1},
+	}
+
+	lines := strings.Split(RenderGraph(points, graphAxisWidth+4, 4, theme), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected 2 chart rows, the loss row and the time axis, got %q", lines)
+	}
+	if !strings.Contains(lines[0], " 30ms │") || !strings.Contains(lines[1], "     0 │") {
+		t.Errorf("Expected the RTT scale, got %q", lines[:2])
+	}
+	// The line is broken by the failure and rises from 20ms to 30ms
+	if !strings.Contains(lines[0], "│[blue]⠀⠀⡠⠊[white]") || !strings.Contains(lines[1], "│[blue]⠂⠀⠀⠀[white]") {
+		t.Errorf("Unexpected chart %q", lines[:2])
+	}
+	if !strings.Contains(lines[2], "loss └─[red]×[gray][yellow]•[gray]─") {
+		t.Errorf("Expected loss markers, got %q", lines[2])
+	}
+	if !strings.Contains(lines[3], "15:30:00") || !strings.Contains(lines[3], "15:30:03") {
+		t.Errorf("Expected the time range, got %q", lines[3])
+	}
+
+	if got := RenderGraph(nil, 40, 10, theme); !strings.Contains(got, "No results") {
+		t.Errorf("Expected a note without points, got %q", got)
+	}
+	if got := RenderGraph(points, graphAxisWidth+1, 10, theme); got != "" {
+		t.Errorf("Expected nothing in a too small area, got %q", got)
+	}
+}
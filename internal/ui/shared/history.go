@@ -0,0 +1,151 @@
+package shared
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rivo/tview"
+
+	"github.com/servak/mping/internal/stats"
+)
+
+// HistoryEntryStatus returns the status label of a history entry, its color
+// and a one-line summary of its details
+func HistoryEntryStatus(entry stats.HistoryEntry, theme *Theme) (status, color, details string) {
+	if entry.IsEvent() {
+		// Non-probe events such as address changes, duplicate and late replies
+		status, color = "EVENT", theme.Accent
+		if entry.Duplicate {
+			status, color = "DUP", theme.Warning
+		} else if entry.Late {
+			status, color = "LATE", theme.Warning
+		}
+		return status, color, entry.Event
+	}
+
+	if !entry.Success {
+		// Show error message for failed entries
+		if entry.Error != "" {
+			details = fmt.Sprintf("[%s]%s[%s]", theme.Error, entry.Error, theme.Primary)
+		}
+		// Failures may carry details too (e.g. ICMP error source, TCP failure kind)
+		if entry.Details != nil {
+			details = strings.TrimSpace(details + " " + formatProbeDetails(entry.Details))
+		}
+		return "FAIL", theme.Error, details
+	}
+
+	// Show probe-specific details for successful entries
+	details = formatProbeDetails(entry.Details)
+	if entry.Reordered {
+		details = strings.TrimSpace(fmt.Sprintf("[%s]reordered[%s] %s", theme.Warning, theme.Primary, details))
+	}
+	return "OK", theme.Success, details
+}
+
+// FilterHistory returns the entries that are failed probes if failuresOnly is
+// set and whose error or event text contains search (case-insensitive)
+func FilterHistory(entries []stats.HistoryEntry, failuresOnly bool, search string) []stats.HistoryEntry {
+	search = strings.ToLower(search)
+	res := make([]stats.HistoryEntry, 0, len(entries))
+	for _, e := range entries {
+		if failuresOnly && (e.Success || e.IsEvent()) {
+			continue
+		}
+		if search != "" && !strings.Contains(strings.ToLower(e.Error+"\n"+e.Event), search) {
+			continue
+		}
+		res = append(res, e)
+	}
+	return res
+}
+
+// FormatHistoryEntry shows every field of a history entry, including all of
+// its probe details
+func FormatHistoryEntry(entry stats.HistoryEntry, theme *Theme) string {
+	status, color, _ := HistoryEntryStatus(entry, theme)
+	var b strings.Builder
+	field := func(name string, value any) {
+		fmt.Fprintf(&b, "[%s]%s:[%s] %v\n", theme.Accent, name, theme.Primary, value)
+	}
+	field("Time", entry.Timestamp.Format("2006-01-02 15:04:05.000"))
+	field("Status", fmt.Sprintf("[%s]%s[%s]", color, status, theme.Primary))
+	field("RTT", strings.TrimSpace(DurationFormater(entry.RTT)))
+	if entry.Error != "" {
+		field("Error", tview.Escape(entry.Error))
+	}
+	if entry.Event != "" {
+		field("Event", tview.Escape(entry.Event))
+	}
+	if entry.Reordered {
+		field("Reordered", "yes")
+	}
+
+	d := entry.Details
+	if d == nil {
+		return strings.TrimSuffix(b.String(), "\n")
+	}
+	field("Probe", d.ProbeType)
+	switch {
+	case d.ICMP != nil:
+		field("Sequence", d.ICMP.Sequence)
+		field("Packet Size", d.ICMP.PacketSize)
+		field("ICMP Type/Code", fmt.Sprintf("%d/%d", d.ICMP.ICMPType, d.ICMP.ICMPCode))
+		field("Checksum", fmt.Sprintf("0x%04x", d.ICMP.Checksum))
+		if d.ICMP.From != "" {
+			field("From", d.ICMP.From)
+		}
+		field("Payload", tview.Escape(d.ICMP.Payload))
+	case d.HTTP != nil:
+		field("Status Code", d.HTTP.StatusCode)
+		field("Response Size", d.HTTP.ResponseSize)
+		for _, r := range d.HTTP.Redirects {
+			field("Redirect", tview.Escape(r))
+		}
+		if len(d.HTTP.Headers) > 0 {
+			fmt.Fprintf(&b, "[%s]Headers:[%s]\n", theme.Accent, theme.Primary)
+			names := make([]string, 0, len(d.HTTP.Headers))
+			for name := range d.HTTP.Headers {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				fmt.Fprintf(&b, "  %s: %s\n", tview.Escape(name), tview.Escape(d.HTTP.Headers[name]))
+			}
+		}
+	case d.DNS != nil:
+		proto := "udp"
+		if d.DNS.UseTCP {
+			proto = "tcp"
+		}
+		field("Server", fmt.Sprintf("%s:%d (%s)", d.DNS.Server, d.DNS.Port, proto))
+		field("Query", fmt.Sprintf("%s %s", d.DNS.Domain, d.DNS.RecordType))
+		field("Response Code", d.DNS.ResponseCode)
+		field("Answers", d.DNS.AnswerCount)
+		for _, a := range d.DNS.Answers {
+			fmt.Fprintf(&b, "  %s\n", tview.Escape(a))
+		}
+	case d.NTP != nil:
+		field("Server", fmt.Sprintf("%s:%d", d.NTP.Server, d.NTP.Port))
+		field("Stratum", d.NTP.Stratum)
+		field("Offset", time.Duration(d.NTP.Offset)*time.Microsecond)
+		field("Precision", d.NTP.Precision)
+	case d.TCP != nil:
+		field("Method", d.TCP.Method)
+		field("Remote", d.TCP.RemoteAddr)
+		if d.TCP.LocalAddr != "" {
+			field("Local", d.TCP.LocalAddr)
+		}
+		field("Connect Time", time.Duration(d.TCP.ConnectTime)*time.Microsecond)
+		if d.TCP.FailureKind != "" {
+			field("Failure", d.TCP.FailureKind)
+		}
+		if d.TCP.Banner != "" {
+			field("Banner", tview.Escape(d.TCP.Banner))
+			field("Banner Time", time.Duration(d.TCP.BannerTime)*time.Microsecond)
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
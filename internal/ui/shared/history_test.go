@@ -0,0 +1,109 @@
+package shared
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/servak/mping/internal/prober"
+	"github.com/servak/mping/internal/stats"
+)
+
+func TestFilterHistory(t *testing.T) {
+	entries := []stats.HistoryEntry{
+		{Success: true, RTT: time.Millisecond},
+		{Success: false, Error: "Connection refused"},
+		{Event: "address changed to 192.0.2.2"},
+		{Success: false, Error: "timeout"},
+	}
+	tests := []struct {
+		name         string
+		failuresOnly bool
+		search       string
+		expected     int
+	}{
+		{"all", false, "", 4},
+		{"failures only", true, "", 2},
+		{"search error", false, "refused", 1},
+		{"search is case-insensitive", false, "CONNECTION", 1},
+		{"search event", false, "address", 1},
+		{"failures with search", true, "address", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FilterHistory(entries, tt.failuresOnly, tt.search); len(got) != tt.expected {
+				t.Errorf("FilterHistory() = %+v, want %d entries", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFormatHistoryEntry(t *testing.T) {
+	theme := &Theme{Primary: "white", Accent: "blue", Success: "green", Error: "red"}
+	at := time.Date(2024, 1, 1, 15, 30, 0, 0, time.Local)
+	tests := []struct {
+		name     string
+		entry    stats.HistoryEntry
+		expected []string
+	}{
+		{
+			name: "http",
+			entry: stats.HistoryEntry{Timestamp: at, Success: true, RTT: 42 * time.Millisecond, Details: &prober.ProbeDetails{
+				ProbeType: "http",
+				HTTP: &prober.HTTPDetails{
+					StatusCode:   200,
+					ResponseSize: 512,
+					Headers:      map[string]string{"Server": "nginx", "Content-Type": "text/html"},
+					Redirects:    []string{"https://example.com/"},
+				},
+			}},
+			expected: []string{
+				"[blue]Time:[white] 2024-01-01 15:30:00.000",
+				"[blue]Status:[white] [green]OK[white]",
+				"[blue]RTT:[white] 42ms",
+				"[blue]Status Code:[white] 200",
+				"[blue]Redirect:[white] https://example.com/",
+				"[blue]Headers:[white]\n  Content-Type: text/html\n  Server: nginx",
+			},
+		},
+		{
+			name: "dns",
+			entry: stats.HistoryEntry{Timestamp: at, Success: true, Details: &prober.ProbeDetails{
+				ProbeType: "dns",
+				DNS: &prober.DNSDetails{
+					Server: "8.8.8.8", Port: 53, Domain: "example.com", RecordType: "A", AnswerCount: 2,
+					Answers: []string{"example.com. 300 IN A 192.0.2.1", "example.com. 300 IN A 192.0.2.2"},
+				},
+			}},
+			expected: []string{
+				"[blue]Server:[white] 8.8.8.8:53 (udp)",
+				"[blue]Query:[white] example.com A",
+				"  example.com. 300 IN A 192.0.2.1\n  example.com. 300 IN A 192.0.2.2",
+			},
+		},
+		{
+			name: "icmp failure",
+			entry: stats.HistoryEntry{Timestamp: at, Error: "destination unreachable", Details: &prober.ProbeDetails{
+				ProbeType: "icmp",
+				ICMP:      &prober.ICMPDetails{Sequence: 7, PacketSize: 64, ICMPType: 3, ICMPCode: 1, Payload: "[mping]", From: "192.0.2.254"},
+			}},
+			expected: []string{
+				"[blue]Status:[white] [red]FAIL[white]",
+				"[blue]Error:[white] destination unreachable",
+				"[blue]ICMP Type/Code:[white] 3/1",
+				"[blue]From:[white] 192.0.2.254",
+				"[blue]Payload:[white] [mping[]",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := FormatHistoryEntry(tt.entry, theme)
+			for _, expected := range tt.expected {
+				if !strings.Contains(result, expected) {
+					t.Errorf("FormatHistoryEntry() missing %q:\n%s", expected, result)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package shared
+
+import (
+	"strings"
+	"time"
+
+	"github.com/servak/mping/internal/stats"
+)
+
+// sparkLevels are the bars of a sparkline, lowest first
+var sparkLevels = []rune("▁▂▃▄▅▆▇█")
+
+// sparkFailure marks a failed probe in a sparkline
+const sparkFailure = '×'
+
+// ProbeResults returns the entries that are probe results, dropping events,
+// duplicate and late replies
+func ProbeResults(entries []stats.HistoryEntry) []stats.HistoryEntry {
+	res := make([]stats.HistoryEntry, 0, len(entries))
+	for _, e := range entries {
+		if !e.IsEvent() && !e.Duplicate && !e.Late {
+			res = append(res, e)
+		}
+	}
+	return res
+}
+
+// Sparkline draws the RTTs of the last width probe results of entries (newest
+// first, as returned by GetRecentHistory) with the oldest on the left. Bars are
+// scaled between the lowest and highest RTT shown, and failed probes are
+// marked in failColor. The result is padded on the left to width cells.
+func Sparkline(entries []stats.HistoryEntry, width int, failColor string) string {
+	results := ProbeResults(entries)
+	if len(results) > width {
+		results = results[:width]
+	}
+	var lo, hi time.Duration
+	for _, e := range results {
+		if !e.Success {
+			continue
+		}
+		if lo == 0 || e.RTT < lo {
+			lo = e.RTT
+		}
+		hi = max(hi, e.RTT)
+	}
+
+	var b strings.Builder
+	b.WriteString(strings.Repeat(" ", width-len(results)))
+	failing := false
+	for i := len(results) - 1; i >= 0; i-- {
+		e := results[i]
+		if e.Success == failing {
+			if failing {
+				b.WriteString("[-]")
+			} else {
+				b.WriteString("[" + failColor + "]")
+			}
+			failing = !failing
+		}
+		if !e.Success {
+			b.WriteRune(sparkFailure)
+			continue
+		}
+		level := len(sparkLevels) / 2 // All RTTs equal
+		if hi > lo {
+			level = int(int64(e.RTT-lo) * int64(len(sparkLevels)-1) / int64(hi-lo))
+		}
+		b.WriteRune(sparkLevels[level])
+	}
+	if failing {
+		b.WriteString("[-]")
+	}
+	return b.String()
+}
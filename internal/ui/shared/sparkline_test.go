@@ -0,0 +1,49 @@
+package shared
+
+import (
+	"testing"
+	"time"
+
+	"github.com/servak/mping/internal/stats"
+)
+
+func TestSparkline(t *testing.T) {
+	ok := func(ms int) stats.HistoryEntry {
+		return stats.HistoryEntry{Success: true, RTT: time.Duration(ms) * time.Millisecond}
+	}
+	fail := stats.HistoryEntry{Success: false, Error: "timeout"}
+
+	tests := []struct {
+		name     string
+		entries  []stats.HistoryEntry // Newest first
+		width    int
+		expected string
+	}{
+		{"empty", nil, 3, "   "},
+		{"oldest on the left", []stats.HistoryEntry{ok(80), ok(10), ok(45)}, 3, "▄▁█"},
+		{"padded", []stats.HistoryEntry{ok(20), ok(10)}, 4, "  ▁█"},
+		{"truncated to newest", []stats.HistoryEntry{ok(10), ok(80), ok(10), ok(80)}, 2, "█▁"},
+		{"equal RTTs", []stats.HistoryEntry{ok(10), ok(10)}, 2, "▅▅"},
+		{"failures", []stats.HistoryEntry{ok(10), fail, fail, ok(20)}, 4, "█[red]××[-]▁"},
+		{"failure last", []stats.HistoryEntry{fail, ok(10)}, 2, "▅[red]×[-]"},
+		{
+			"skips events",
+			[]stats.HistoryEntry{
+				ok(10),
+				{Event: "address changed"},
+				{Success: true, RTT: time.Second, Duplicate: true},
+				{Success: true, RTT: time.Second, Late: true},
+				ok(20),
+			},
+			3,
+			" █▁",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sparkline(tt.entries, tt.width, "red"); got != tt.expected {
+				t.Errorf("Sparkline() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
@@ -18,8 +18,38 @@ type TableData struct {
 	Metrics []stats.Metrics // Keep reference for interactive row selection
 }
 
-// NewTableData creates TableData from metrics
-func NewTableData(metrics []stats.Metrics, sortKey stats.Key, ascending bool) *TableData {
+// Columns selects the optional columns of the host table
+type Columns struct {
+	Spread   bool // Jitter, StdDev and the percentiles
+	Windowed bool // The statistics cover a recent window, which has no jitter or percentiles
+}
+
+// Shows reports whether the column of k is part of the table
+func (c Columns) Shows(k stats.Key) bool {
+	if k.Spread() && !c.Spread {
+		return false
+	}
+	if c.Windowed && (k == stats.Jitter || k.Quantile() > 0) {
+		return false
+	}
+	return true
+}
+
+// VisibleColumns returns the cells of a host table row (one per stats.Keys()
+// column, then any trailing columns) that c shows
+func VisibleColumns[T any](cells []T, c Columns) []T {
+	res := make([]T, 0, len(cells))
+	for i, cell := range cells {
+		if i < len(stats.Keys()) && !c.Shows(stats.Key(i)) {
+			continue
+		}
+		res = append(res, cell)
+	}
+	return res
+}
+
+// NewTableData creates TableData from metrics with the columns c shows
+func NewTableData(metrics []stats.Metrics, sortKey stats.Key, ascending bool, c Columns) *TableData {
 	// Generate headers with sort arrows
 	headers := []string{
 		headerWithArrow("Host", stats.Host, sortKey, ascending),
@@ -31,6 +61,12 @@ func NewTableData(metrics []stats.Metrics, sortKey stats.Key, ascending bool) *T
 		headerWithArrow("Avg", stats.Avg, sortKey, ascending),
 		headerWithArrow("Best", stats.Best, sortKey, ascending),
 		headerWithArrow("Worst", stats.Worst, sortKey, ascending),
+		headerWithArrow("Jitter", stats.Jitter, sortKey, ascending),
+		headerWithArrow("StdDev", stats.StdDev, sortKey, ascending),
+		headerWithArrow("P50", stats.P50, sortKey, ascending),
+		headerWithArrow("P90", stats.P90, sortKey, ascending),
+		headerWithArrow("P95", stats.P95, sortKey, ascending),
+		headerWithArrow("P99", stats.P99, sortKey, ascending),
 		headerWithArrow("LastSuccTime", stats.LastSuccTime, sortKey, ascending),
 		headerWithArrow("LastFailTime", stats.LastFailTime, sortKey, ascending),
 		"FAIL Reason",
@@ -42,8 +78,12 @@ func NewTableData(metrics []stats.Metrics, sortKey stats.Key, ascending bool) *T
 	tf := TimeFormater
 
 	for i, m := range metrics {
+		name := m.GetName()
+		if m.GetGroup() != "" {
+			name = "  " + name // Indent members under their group's aggregate row
+		}
 		rows[i] = []string{
-			m.GetName(),
+			name,
 			fmt.Sprintf("%d", m.GetTotal()),
 			fmt.Sprintf("%d", m.GetSuccessful()),
 			fmt.Sprintf("%d", m.GetFailed()),
@@ -52,11 +92,30 @@ func NewTableData(metrics []stats.Metrics, sortKey stats.Key, ascending bool) *T
 			df(m.GetAverageRTT()),
 			df(m.GetMinimumRTT()),
 			df(m.GetMaximumRTT()),
+			df(m.GetJitter()),
+			df(m.GetStdDev()),
+			df(m.GetPercentileRTT(stats.P50.Quantile())),
+			df(m.GetPercentileRTT(stats.P90.Quantile())),
+			df(m.GetPercentileRTT(stats.P95.Quantile())),
+			df(m.GetPercentileRTT(stats.P99.Quantile())),
 			tf(m.GetLastSuccTime()),
 			tf(m.GetLastFailTime()),
 			m.GetLastFailDetail(),
 		}
+		if c, ok := m.(stats.Comparison); ok {
+			// Dual-stack aggregate rows show IPv6 minus IPv4
+			rows[i][4] = fmt.Sprintf("%+5.1f%%", c.GetLossDelta())
+			rows[i][5] = DeltaFormater(c.GetLastRTTDelta())
+			rows[i][6] = DeltaFormater(c.GetAverageRTTDelta())
+			rows[i][7] = DeltaFormater(c.GetMinimumRTTDelta())
+			rows[i][8] = DeltaFormater(c.GetMaximumRTTDelta())
+			for j := stats.Jitter; j <= stats.P99; j++ {
+				rows[i][j] = "-" // No deltas for the spread columns
+			}
+		}
+		rows[i] = VisibleColumns(rows[i], c)
 	}
+	headers = VisibleColumns(headers, c)
 
 	return &TableData{
 		Headers: headers,
@@ -65,6 +124,43 @@ func NewTableData(metrics []stats.Metrics, sortKey stats.Key, ascending bool) *T
 	}
 }
 
+// NewOutageTableData creates a summary of each target's outages
+func NewOutageTableData(metrics []stats.Metrics) *TableData {
+	headers := []string{"Host", "Outages", "Downtime", "MTTR", "Availability", "Last Outage", "First Error"}
+	rows := make([][]string, len(metrics))
+	for i, m := range metrics {
+		name := m.GetName()
+		if m.GetGroup() != "" {
+			name = "  " + name
+		}
+		availability, lastOutage, firstError := "-", "-", ""
+		if m.GetSuccessful()+m.GetFailed() > 0 {
+			availability = fmt.Sprintf("%.2f%%", m.GetAvailability())
+		}
+		if incidents := m.GetIncidents(); len(incidents) > 0 {
+			lastOutage = TimeFormater(incidents[0].Start)
+			if incidents[0].Ongoing() {
+				lastOutage += " (ongoing)"
+			}
+			firstError = incidents[0].FirstError
+		}
+		rows[i] = []string{
+			name,
+			fmt.Sprintf("%d", m.GetOutages()),
+			ElapsedFormater(m.GetDowntime()),
+			ElapsedFormater(m.GetMTTR()),
+			availability,
+			lastOutage,
+			firstError,
+		}
+	}
+	return &TableData{
+		Headers: headers,
+		Rows:    rows,
+		Metrics: metrics,
+	}
+}
+
 // ToGoPrettyTable converts to go-pretty table format for final output only
 func (td *TableData) ToGoPrettyTable() table.Writer {
 	text.OverrideRuneWidthEastAsianWidth(false)
@@ -115,6 +211,12 @@ func (td *TableData) ToTviewTable() *tview.Table {
 		tview.AlignRight,  // Avg
 		tview.AlignRight,  // Best
 		tview.AlignRight,  // Worst
+		tview.AlignRight,  // Jitter
+		tview.AlignRight,  // StdDev
+		tview.AlignRight,  // P50
+		tview.AlignRight,  // P90
+		tview.AlignRight,  // P95
+		tview.AlignRight,  // P99
 		tview.AlignCenter, // LastSuccTime
 		tview.AlignCenter, // LastFailTime
 		tview.AlignLeft,   // FAIL Reason
@@ -2,11 +2,13 @@ package tui
 
 import (
 	"context"
+	"math"
 	"time"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 
+	"github.com/servak/mping/internal/prober"
 	"github.com/servak/mping/internal/stats"
 	"github.com/servak/mping/internal/ui/shared"
 	"github.com/servak/mping/internal/ui/tui/state"
@@ -36,6 +38,7 @@ func NewTUIApp(mm stats.MetricsManager, cfg *shared.Config, interval, timeout ti
 	app := tview.NewApplication()
 	app.EnableMouse(true)
 	uiState := state.NewUIState()
+	uiState.SetSpreadColumns(cfg.SpreadColumns)
 	layout := NewLayoutManager(uiState, mm, cfg, interval, timeout)
 
 	tuiApp := &TUIApp{
@@ -63,6 +66,11 @@ func (a *TUIApp) Run() error {
 	return a.app.Run()
 }
 
+// SetQueueStatus shows the depth of the probe event queue and any dropped events
+func (a *TUIApp) SetQueueStatus(queue prober.QueueStatus) {
+	a.layout.SetQueueStatus(queue)
+}
+
 // Update refreshes the display content
 func (a *TUIApp) Update() {
 	a.app.QueueUpdateDraw(func() {
@@ -87,6 +95,9 @@ func (a *TUIApp) setupCallbacks() {
 	// Set filter input done function
 	a.layout.SetFilterDoneFunc(a.handleFilterDone)
 
+	// Set history search input done function
+	a.layout.GetHostDetailPanel().GetSearchInput().SetDoneFunc(a.handleHistorySearchDone)
+
 	// Set row selection callback
 	a.layout.GetHostListPanel().SetSelectedFunc(a.handleRowSelection)
 
@@ -114,11 +125,37 @@ func (a *TUIApp) setupKeyBindings() {
 			return event
 		}
 
+		// When the graph is visible
+		if a.layout.IsGraphShown() {
+			switch event.Key() {
+			case tcell.KeyEscape:
+				a.layout.HideGraph()
+				return nil
+			}
+			switch event.Rune() {
+			case 'p':
+				a.layout.HideGraph()
+			case '+':
+				a.layout.ZoomGraph(false)
+			case '-':
+				a.layout.ZoomGraph(true)
+			case 'q':
+				a.Close()
+			}
+			return nil
+		}
+
 		// When filter input is visible, let it handle its own keys
 		if a.layout.IsFilterShown() {
 			return event
 		}
 
+		// When the detail view shows the full history, navigation moves
+		// through the history instead of the host list
+		if a.layout.IsHistoryShown() && a.handleHistoryKey(event) {
+			return nil
+		}
+
 		// Main screen key bindings
 		switch event.Key() {
 		case tcell.KeyEscape:
@@ -139,6 +176,15 @@ func (a *TUIApp) setupKeyBindings() {
 		case 'v':
 			a.toggleDetailView()
 			return nil
+		case 'H':
+			a.layout.ToggleHistory()
+			return nil
+		case 'p':
+			// The graph is opened from the detail view
+			if a.layout.GetDisplayMode() == ListWithDetail {
+				a.layout.ShowGraph()
+			}
+			return nil
 		case 's':
 			a.nextSort()
 			return nil
@@ -157,6 +203,12 @@ func (a *TUIApp) setupKeyBindings() {
 		case 't':
 			a.cycleTheme()
 			return nil
+		case 'w':
+			a.cycleStatsWindow()
+			return nil
+		case 'x':
+			a.toggleSpreadColumns()
+			return nil
 		}
 
 		// Delegate navigation to layout
@@ -186,8 +238,12 @@ NAVIGATION:
   r            Reverse sort order     
   R            Reset all metrics      
   v            Toggle detail view     
+  p            RTT graph (detail view)
+  H            History (detail view)  
   /            Filter hosts           
   t            Cycle theme            
+  w            Cycle stats window     
+  x            Toggle spread columns  
   h            Show/hide this help    
   q, Ctrl+C    Quit application       
 
@@ -196,6 +252,17 @@ FILTER:
   Enter        Apply filter           
   Esc          Cancel/Clear filter    
 
+HISTORY:                             
+  j, k, g, G   Move through entries   
+  Enter        Expand/collapse entry  
+  f            Failures only          
+  /            Search error text      
+  H, Esc       Close history          
+
+GRAPH:                               
+  +, -         Zoom in/out            
+  p, Esc       Close graph            
+
 Press 'h' or Esc to close           `
 
 	return tview.NewModal().
@@ -210,20 +277,60 @@ Press 'h' or Esc to close           `
 func (a *TUIApp) nextSort() {
 	keys := stats.Keys()
 	currentKey := a.state.GetSortKey()
-	if int(currentKey+1) < len(keys) {
-		a.state.SetSortKey(currentKey + 1)
-	} else {
-		a.state.SetSortKey(0)
+	for {
+		if int(currentKey+1) < len(keys) {
+			currentKey++
+		} else {
+			currentKey = 0
+		}
+		// Skip the keys of hidden columns
+		if a.columns().Shows(currentKey) {
+			break
+		}
 	}
+	a.state.SetSortKey(currentKey)
 }
 
 func (a *TUIApp) prevSort() {
 	keys := stats.Keys()
 	currentKey := a.state.GetSortKey()
-	if int(currentKey) == 0 {
-		a.state.SetSortKey(stats.Key(len(keys) - 1))
-	} else {
-		a.state.SetSortKey(currentKey - 1)
+	for {
+		if int(currentKey) == 0 {
+			currentKey = stats.Key(len(keys) - 1)
+		} else {
+			currentKey--
+		}
+		if a.columns().Shows(currentKey) {
+			break
+		}
+	}
+	a.state.SetSortKey(currentKey)
+}
+
+// columns returns the optional table columns to show
+func (a *TUIApp) columns() shared.Columns {
+	return shared.Columns{
+		Spread:   a.state.ShowSpreadColumns(),
+		Windowed: a.state.GetStatsWindow() > 0,
+	}
+}
+
+// toggleSpreadColumns shows or hides the RTT spread columns
+func (a *TUIApp) toggleSpreadColumns() {
+	a.state.ToggleSpreadColumns()
+	a.keepSortVisible()
+}
+
+// cycleStatsWindow switches the period the table covers
+func (a *TUIApp) cycleStatsWindow() {
+	a.state.CycleStatsWindow()
+	a.keepSortVisible()
+}
+
+// keepSortVisible moves the sort off a column that is no longer shown
+func (a *TUIApp) keepSortVisible() {
+	if !a.columns().Shows(a.state.GetSortKey()) {
+		a.nextSort()
 	}
 }
 
@@ -268,6 +375,74 @@ func (a *TUIApp) handleFilterDone(key tcell.Key) {
 	}
 }
 
+// handleHistoryKey handles keys of the full history view and reports whether
+// the key was used
+func (a *TUIApp) handleHistoryKey(event *tcell.EventKey) bool {
+	detail := a.layout.GetHostDetailPanel()
+	// The search input handles its own keys
+	if detail.IsSearchShown() {
+		return false
+	}
+
+	switch event.Key() {
+	case tcell.KeyEscape:
+		// Clear the search first, then close the history
+		if detail.GetSearch() != "" {
+			detail.SetSearch("")
+		} else {
+			detail.HideHistory()
+		}
+		return true
+	case tcell.KeyEnter:
+		detail.ToggleExpanded()
+		return true
+	case tcell.KeyDown:
+		detail.ScrollHistory(1)
+		return true
+	case tcell.KeyUp:
+		detail.ScrollHistory(-1)
+		return true
+	case tcell.KeyPgDn:
+		detail.ScrollHistory(detail.HistoryPageSize())
+		return true
+	case tcell.KeyPgUp:
+		detail.ScrollHistory(-detail.HistoryPageSize())
+		return true
+	}
+
+	switch event.Rune() {
+	case 'j':
+		detail.ScrollHistory(1)
+	case 'k':
+		detail.ScrollHistory(-1)
+	case 'g':
+		detail.ScrollHistory(math.MinInt / 2)
+	case 'G':
+		detail.ScrollHistory(math.MaxInt / 2)
+	case 'd':
+		detail.ScrollHistory(detail.HistoryPageSize())
+	case 'u':
+		detail.ScrollHistory(-detail.HistoryPageSize())
+	case 'f':
+		detail.ToggleFailuresOnly()
+	case '/':
+		detail.ShowSearchInput()
+		a.app.SetFocus(detail.GetSearchInput())
+	default:
+		return false
+	}
+	return true
+}
+
+func (a *TUIApp) handleHistorySearchDone(key tcell.Key) {
+	detail := a.layout.GetHostDetailPanel()
+	if key == tcell.KeyEnter {
+		detail.SetSearch(detail.GetSearchInput().GetText())
+	}
+	detail.HideSearchInput()
+	a.layout.RestoreFocus()
+}
+
 // Help modal related methods
 func (a *TUIApp) showHelp() {
 	a.layout.ShowPage("help")
@@ -292,7 +467,7 @@ func (a *TUIApp) handleRowSelection(row, col int) {
 
 	// Get filtered metrics directly
 	metrics := a.getFilteredMetrics()
-	tableData := shared.NewTableData(metrics, a.state.GetSortKey(), a.state.IsAscending())
+	tableData := shared.NewTableData(metrics, a.state.GetSortKey(), a.state.IsAscending(), a.columns())
 
 	// Convert table row to data row (subtract 1 for header)
 	dataRow := row - 1
@@ -304,8 +479,10 @@ func (a *TUIApp) handleRowSelection(row, col int) {
 
 // getFilteredMetrics returns filtered metrics based on current state
 func (a *TUIApp) getFilteredMetrics() []stats.Metrics {
-	metrics := a.mm.SortBy(a.state.GetSortKey(), a.state.IsAscending())
-	return shared.FilterMetrics(metrics, a.state.GetFilter())
+	k, ascending := a.state.GetSortKey(), a.state.IsAscending()
+	metrics := shared.FilterMetrics(a.mm.SortBy(k, ascending), a.state.GetFilter())
+	metrics = shared.WindowMetrics(metrics, a.state.GetStatsWindow(), k, ascending)
+	return stats.GroupMetrics(metrics)
 }
 
 // Theme-related methods
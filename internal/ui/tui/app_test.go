@@ -114,6 +114,45 @@ func TestTUIAppSortMethods(t *testing.T) {
 	}
 }
 
+func TestTUIAppSortSkipsHiddenColumns(t *testing.T) {
+	mm := stats.NewMetricsManager()
+	cfg := shared.DefaultConfig()
+	app := NewTUIApp(mm, cfg, time.Second, time.Second)
+
+	// The spread columns are hidden by default, so sorting skips them
+	app.state.SetSortKey(stats.Worst)
+	app.nextSort()
+	if got := app.state.GetSortKey(); got != stats.LastSuccTime {
+		t.Errorf("nextSort() from Worst = %v, want %v", got, stats.LastSuccTime)
+	}
+	app.prevSort()
+	if got := app.state.GetSortKey(); got != stats.Worst {
+		t.Errorf("prevSort() from LastSuccTime = %v, want %v", got, stats.Worst)
+	}
+
+	// Shown, they can be sorted by; hiding them again moves the sort off
+	app.toggleSpreadColumns()
+	app.nextSort()
+	if got := app.state.GetSortKey(); got != stats.Jitter {
+		t.Errorf("nextSort() from Worst with spread columns = %v, want %v", got, stats.Jitter)
+	}
+	// Windowed statistics have no jitter, so switching to a window moves the sort too
+	app.cycleStatsWindow()
+	if got := app.state.GetSortKey(); got != stats.StdDev {
+		t.Errorf("Sort key after switching to a window = %v, want %v", got, stats.StdDev)
+	}
+	app.toggleSpreadColumns()
+	if got := app.state.GetSortKey(); got.Spread() {
+		t.Errorf("Sort key %v still on a hidden column", got)
+	}
+
+	cfg = shared.DefaultConfig()
+	cfg.SpreadColumns = true
+	if !NewTUIApp(mm, cfg, time.Second, time.Second).state.ShowSpreadColumns() {
+		t.Error("Expected spread_columns to show the spread columns")
+	}
+}
+
 func TestTUIAppResetMetrics(t *testing.T) {
 	mm := stats.NewMetricsManager()
 	cfg := shared.DefaultConfig()
@@ -179,4 +218,34 @@ func TestTUIAppCreateHelpModal(t *testing.T) {
 	if modal == nil {
 		t.Error("createHelpModal() returned nil")
 	}
-}
\ No newline at end of file
+}
+
+func TestLayoutGraph(t *testing.T) {
+	mm := stats.NewMetricsManager()
+	mm.Register("google.com", "google.com")
+	app := NewTUIApp(mm, nil, time.Second, time.Second)
+	app.layout.UpdateAll()
+
+	if app.layout.IsGraphShown() {
+		t.Fatal("Expected the graph to be hidden initially")
+	}
+	app.layout.ShowGraph()
+	if !app.layout.IsGraphShown() {
+		t.Fatal("Expected the graph to be shown")
+	}
+
+	app.layout.ZoomGraph(true)
+	app.layout.ZoomGraph(true)
+	if r := app.layout.graph.Range(); r != shared.GraphRanges[2] {
+		t.Errorf("Expected to zoom out twice, got %+v", r)
+	}
+	app.layout.ZoomGraph(false)
+	if r := app.layout.graph.Range(); r != shared.GraphRanges[1] {
+		t.Errorf("Expected to zoom in, got %+v", r)
+	}
+
+	app.layout.HideGraph()
+	if app.layout.IsGraphShown() {
+		t.Error("Expected the graph to be hidden")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 
+	"github.com/servak/mping/internal/prober"
 	"github.com/servak/mping/internal/stats"
 	"github.com/servak/mping/internal/ui/shared"
 	"github.com/servak/mping/internal/ui/tui/panels"
@@ -31,6 +32,7 @@ type LayoutManager struct {
 	hostList   *panels.HostListPanel
 	footer     *panels.FooterPanel
 	hostDetail *panels.HostDetailPanel
+	graph      *panels.GraphPanel
 
 	// Filter input
 	filterInput *tview.InputField
@@ -57,11 +59,12 @@ func NewLayoutManager(uiState *state.UIState, mm stats.MetricsManager, config *s
 }
 
 // setupPanels initializes all panels
-func (l *LayoutManager) setupPanels(uiState *state.UIState, mm stats.MetricsProvider, config *shared.Config, interval, timeout time.Duration) {
-	l.header = panels.NewHeaderPanel(uiState, config, interval, timeout)
+func (l *LayoutManager) setupPanels(uiState *state.UIState, mm stats.MetricsManager, config *shared.Config, interval, timeout time.Duration) {
+	l.header = panels.NewHeaderPanel(uiState, mm, config, interval, timeout)
 	l.hostList = panels.NewHostListPanel(uiState, mm, config)
 	l.footer = panels.NewFooterPanel(config)
 	l.hostDetail = panels.NewHostDetailPanel(config)
+	l.graph = panels.NewGraphPanel(config)
 
 	// Setup filter input with theme-aware colors
 	theme := config.GetTheme()
@@ -84,6 +87,7 @@ func (l *LayoutManager) setupLayout() {
 func (l *LayoutManager) setupPages() {
 	l.pages = tview.NewPages()
 	l.pages.AddPage("main", l.root, true, true)
+	l.pages.AddPage("graph", l.graph.GetView(), true, false)
 }
 
 // GetRoot returns the root primitive for the application
@@ -91,6 +95,11 @@ func (l *LayoutManager) GetRoot() tview.Primitive {
 	return l.pages
 }
 
+// SetQueueStatus shows the event queue in the header
+func (l *LayoutManager) SetQueueStatus(queue prober.QueueStatus) {
+	l.header.SetQueueStatus(queue)
+}
+
 // GetHostListPanel returns the host list panel
 func (l *LayoutManager) GetHostListPanel() *panels.HostListPanel {
 	return l.hostList
@@ -146,6 +155,7 @@ func (l *LayoutManager) showDetailView() {
 // hideDetailView switches to single pane layout
 func (l *LayoutManager) hideDetailView() {
 	l.mode = ListOnly
+	l.hostDetail.HideHistory()
 
 	// Rebuild root layout with single pane
 	l.root.Clear()
@@ -154,6 +164,52 @@ func (l *LayoutManager) hideDetailView() {
 		AddItem(l.footer.GetView(), 1, 0, false)
 }
 
+// ToggleHistory switches the detail view between the host details and the
+// full history of the host
+func (l *LayoutManager) ToggleHistory() {
+	if l.mode != ListWithDetail {
+		return
+	}
+	if l.hostDetail.IsHistoryShown() {
+		l.hostDetail.HideHistory()
+	} else {
+		l.hostDetail.ShowHistory()
+	}
+}
+
+// IsHistoryShown returns whether the detail view shows the full history
+func (l *LayoutManager) IsHistoryShown() bool {
+	return l.mode == ListWithDetail && l.hostDetail.IsHistoryShown()
+}
+
+// ShowGraph shows the RTT graph of the selected host full screen
+func (l *LayoutManager) ShowGraph() {
+	l.graph.SetMetrics(l.hostList.GetSelectedMetrics())
+	l.graph.Update()
+	l.pages.ShowPage("graph")
+}
+
+// HideGraph returns from the RTT graph to the main screen
+func (l *LayoutManager) HideGraph() {
+	l.pages.HidePage("graph")
+}
+
+// IsGraphShown returns whether the RTT graph is currently shown
+func (l *LayoutManager) IsGraphShown() bool {
+	name, _ := l.pages.GetFrontPage()
+	return name == "graph"
+}
+
+// ZoomGraph moves the RTT graph to a longer (out) or shorter range
+func (l *LayoutManager) ZoomGraph(out bool) {
+	if out {
+		l.graph.ZoomOut()
+	} else {
+		l.graph.ZoomIn()
+	}
+	l.graph.Update()
+}
+
 // UpdateAll refreshes all panels
 func (l *LayoutManager) UpdateAll() {
 	l.header.Update()
@@ -164,6 +220,9 @@ func (l *LayoutManager) UpdateAll() {
 	if l.mode == ListWithDetail {
 		l.hostDetail.Update()
 	}
+	if l.IsGraphShown() {
+		l.graph.Update()
+	}
 }
 
 // HandleKeyEvent handles key events for navigation
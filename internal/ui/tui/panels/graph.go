@@ -0,0 +1,121 @@
+package panels
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"github.com/servak/mping/internal/stats"
+	"github.com/servak/mping/internal/ui/shared"
+)
+
+// GraphPanel shows a full-screen RTT and loss graph of one host
+type GraphPanel struct {
+	view           *tview.TextView
+	container      *tview.Flex // Container with border
+	currentMetrics stats.Metrics
+	zoom           int // Index into shared.GraphRanges
+	config         *shared.Config
+}
+
+// NewGraphPanel creates a new GraphPanel
+func NewGraphPanel(config *shared.Config) *GraphPanel {
+	view := tview.NewTextView()
+	view.SetDynamicColors(true).
+		SetWrap(false)
+
+	container := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(view, 0, 1, false)
+	container.SetBorder(true)
+
+	return &GraphPanel{
+		view:      view,
+		container: container,
+		config:    config,
+	}
+}
+
+// Update redraws the graph to fit the panel
+func (g *GraphPanel) Update() {
+	theme := g.config.GetTheme()
+	r := g.Range()
+	name := ""
+	if g.currentMetrics != nil {
+		name = g.currentMetrics.GetName()
+	}
+	g.container.
+		SetTitle(fmt.Sprintf(" [%s]RTT Graph: %s (%s) ", theme.Primary, name, r.Name)).
+		SetBackgroundColor(tcell.GetColor(theme.Background)).
+		SetBorderColor(tcell.GetColor(theme.Primary))
+	g.view.SetBackgroundColor(tcell.GetColor(theme.Background))
+	if g.currentMetrics == nil {
+		g.view.SetText("Select a host to view its graph")
+		return
+	}
+
+	points := shared.GraphPoints(g.currentMetrics, r)
+	_, _, width, height := g.view.GetInnerRect()
+	help := fmt.Sprintf("[%s]+/- zoom  p/Esc close[%s]", theme.Secondary, theme.Primary)
+	content := []string{
+		formatGraphSummary(points, theme),
+		"",
+		shared.RenderGraph(points, width, height-4, theme),
+		"",
+		help,
+	}
+	g.view.SetText(strings.Join(content, "\n"))
+}
+
+// formatGraphSummary summarizes the probes shown in the graph
+func formatGraphSummary(points []shared.GraphPoint, theme *shared.Theme) string {
+	var successful, failed int
+	var total, worst time.Duration
+	for _, p := range points {
+		successful += p.Successful
+		failed += p.Failed
+		total += p.RTT * time.Duration(p.Successful)
+		worst = max(worst, p.RTT)
+	}
+	loss, avg := "-", time.Duration(0)
+	if successful+failed > 0 {
+		loss = fmt.Sprintf("%.1f%%", float64(failed)/float64(successful+failed)*100)
+	}
+	if successful > 0 {
+		avg = total / time.Duration(successful)
+	}
+	return fmt.Sprintf("[%s]Probes:[%s] %d  [%s]Loss:[%s] %s  [%s]Avg RTT:[%s] %s  [%s]Max RTT:[%s] %s",
+		theme.Accent, theme.Primary, successful+failed,
+		theme.Accent, theme.Primary, loss,
+		theme.Accent, theme.Primary, strings.TrimSpace(shared.DurationFormater(avg)),
+		theme.Accent, theme.Primary, strings.TrimSpace(shared.DurationFormater(worst)),
+	)
+}
+
+// SetMetrics sets the metrics to graph
+func (g *GraphPanel) SetMetrics(metrics stats.Metrics) {
+	g.currentMetrics = metrics
+}
+
+// Range returns the period the graph currently covers
+func (g *GraphPanel) Range() shared.GraphRange {
+	return shared.GraphRanges[g.zoom]
+}
+
+// ZoomOut widens the graph to the next longer range
+func (g *GraphPanel) ZoomOut() {
+	g.zoom = min(g.zoom+1, len(shared.GraphRanges)-1)
+}
+
+// ZoomIn narrows the graph to the next shorter range
+func (g *GraphPanel) ZoomIn() {
+	g.zoom = max(g.zoom-1, 0)
+}
+
+// GetView returns the underlying tview component
+func (g *GraphPanel) GetView() tview.Primitive {
+	return g.container
+}
@@ -8,6 +8,8 @@ import (
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 
+	"github.com/servak/mping/internal/prober"
+	"github.com/servak/mping/internal/stats"
 	"github.com/servak/mping/internal/ui/shared"
 	"github.com/servak/mping/internal/ui/tui/state"
 )
@@ -16,13 +18,15 @@ import (
 type HeaderPanel struct {
 	view        *tview.TextView
 	renderState state.RenderState
+	status      stats.ProberStatusProvider
+	queue       prober.QueueStatus
 	config      *shared.Config
 	interval    time.Duration
 	timeout     time.Duration
 }
 
 // NewHeaderPanel creates a new HeaderPanel
-func NewHeaderPanel(renderState state.RenderState, config *shared.Config, interval, timeout time.Duration) *HeaderPanel {
+func NewHeaderPanel(renderState state.RenderState, status stats.ProberStatusProvider, config *shared.Config, interval, timeout time.Duration) *HeaderPanel {
 	view := tview.NewTextView().
 		SetDynamicColors(true).
 		SetTextAlign(tview.AlignCenter)
@@ -30,12 +34,18 @@ func NewHeaderPanel(renderState state.RenderState, config *shared.Config, interv
 	return &HeaderPanel{
 		view:        view,
 		renderState: renderState,
+		status:      status,
 		config:      config,
 		interval:    interval,
 		timeout:     timeout,
 	}
 }
 
+// SetQueueStatus shows how far event delivery is behind the probers
+func (h *HeaderPanel) SetQueueStatus(queue prober.QueueStatus) {
+	h.queue = queue
+}
+
 // Update refreshes header display based on current state
 func (h *HeaderPanel) Update() {
 	theme := h.config.GetTheme()
@@ -51,13 +61,33 @@ func (h *HeaderPanel) generateHeaderContent() string {
 
 	var parts []string
 	theme := h.config.GetTheme()
+
+	// Prober-level errors take precedence so they are never missed
+	if h.status != nil {
+		for _, e := range h.status.GetProberErrors() {
+			parts = append(parts, fmt.Sprintf("[%s]%s error at %s: %s[-]", theme.Error, e.Prober, shared.TimeFormater(e.Time), tview.Escape(e.Message)))
+		}
+		for _, n := range h.status.GetProberNotices() {
+			parts = append(parts, fmt.Sprintf("[%s]%s: %s[-]", theme.Warning, n.Prober, tview.Escape(n.Message)))
+		}
+	}
 	if h.config.Title != "" {
 		// Use title from config if available
 		parts = append(parts, fmt.Sprintf("[%s]%s[-]", theme.Primary, h.config.Title))
 	}
 	parts = append(parts, fmt.Sprintf("[%s]Sort: %s[-]", theme.Accent, sortDisplay))
+	if window := h.renderState.GetStatsWindow(); window > 0 {
+		parts = append(parts, fmt.Sprintf("[%s]Stats: last %s[-]", theme.Warning, shared.WindowFormater(window)))
+	}
 	parts = append(parts, fmt.Sprintf("[%s]Interval: %dms[-]", theme.Accent, h.interval.Milliseconds()))
 	parts = append(parts, fmt.Sprintf("[%s]Timeout: %dms[-]", theme.Accent, h.timeout.Milliseconds()))
+	if h.queue != nil {
+		if dropped := h.queue.Dropped(); dropped > 0 {
+			parts = append(parts, fmt.Sprintf("[%s]Queue: %d (%d dropped)[-]", theme.Error, h.queue.QueueDepth(), dropped))
+		} else {
+			parts = append(parts, fmt.Sprintf("[%s]Queue: %d[-]", theme.Accent, h.queue.QueueDepth()))
+		}
+	}
 
 	if filterText != "" {
 		parts = append(parts, fmt.Sprintf("[%s]Filter: %s[-]", theme.Warning, filterText))
@@ -108,8 +138,9 @@ func (f *FooterPanel) generateFooterContent() string {
 	resetText := fmt.Sprintf("[%s]R:reset[-]", theme.Secondary)
 	filterText := fmt.Sprintf("[%s]/:filter[-]", theme.Secondary)
 	themeText := fmt.Sprintf("[%s]t:theme[-]", theme.Secondary)
+	windowText := fmt.Sprintf("[%s]w:window[-]", theme.Secondary)
 	moveText := fmt.Sprintf("[%s]j/k/g/G/u/d:move[-]", theme.Secondary)
-	return fmt.Sprintf("%s  %s  %s  %s  %s  %s  %s  %s", helpText, quitText, sortText, reverseText, resetText, filterText, themeText, moveText)
+	return fmt.Sprintf("%s  %s  %s  %s  %s  %s  %s  %s  %s", helpText, quitText, sortText, reverseText, resetText, filterText, themeText, windowText, moveText)
 }
 
 // GetView returns the underlying tview component
@@ -0,0 +1,53 @@
+package panels
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/servak/mping/internal/prober"
+	"github.com/servak/mping/internal/stats"
+	"github.com/servak/mping/internal/ui/shared"
+)
+
+type mockQueue struct {
+	depth   int
+	dropped uint64
+}
+
+func (q *mockQueue) QueueDepth() int { return q.depth }
+func (q *mockQueue) Dropped() uint64 { return q.dropped }
+
+func TestHeaderQueueStatus(t *testing.T) {
+	config := shared.DefaultConfig()
+	header := NewHeaderPanel(newMockState(), stats.NewMetricsManager(), config, time.Second, time.Second)
+	if content := header.generateHeaderContent(); strings.Contains(content, "Queue:") {
+		t.Errorf("Expected no queue without a queue status, got %q", content)
+	}
+
+	queue := &mockQueue{depth: 12}
+	header.SetQueueStatus(queue)
+	if content := header.generateHeaderContent(); !strings.Contains(content, "Queue: 12[") {
+		t.Errorf("Expected the queue depth, got %q", content)
+	}
+	queue.dropped = 3
+	content := header.generateHeaderContent()
+	if !strings.Contains(content, "Queue: 12 (3 dropped)") || !strings.Contains(content, "["+config.GetTheme().Error+"]Queue") {
+		t.Errorf("Expected dropped events to be highlighted, got %q", content)
+	}
+}
+
+func TestHeaderProberNotice(t *testing.T) {
+	config := shared.DefaultConfig()
+	mm := stats.NewMetricsManager()
+	mm.Record(&prober.Event{Key: "tcp", Result: prober.NOTICE, Message: "SYN probing unavailable, using connect()", SentTime: time.Now()})
+	header := NewHeaderPanel(newMockState(), mm, config, time.Second, time.Second)
+
+	content := header.generateHeaderContent()
+	if !strings.Contains(content, "["+config.GetTheme().Warning+"]tcp: SYN probing unavailable") {
+		t.Errorf("Expected the notice as a warning, got %q", content)
+	}
+	if strings.Contains(content, "error at") {
+		t.Errorf("Expected the notice not to be shown as an error, got %q", content)
+	}
+}
@@ -2,6 +2,7 @@ package panels
 
 import (
 	"fmt"
+	"math"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
@@ -17,6 +18,17 @@ type HostDetailPanel struct {
 	currentHost    string
 	currentMetrics stats.Metrics
 	config         *shared.Config
+
+	// Full history mode
+	history      *tview.Table
+	entryView    *tview.TextView // Expanded entry
+	searchInput  *tview.InputField
+	showHistory  bool
+	showSearch   bool
+	expanded     bool
+	failuresOnly bool
+	search       string
+	entries      []stats.HistoryEntry // Rows of the history table, newest first
 }
 
 // NewHostDetailPanel creates a new HostDetailPanel
@@ -30,10 +42,24 @@ func NewHostDetailPanel(config *shared.Config) *HostDetailPanel {
 		SetDirection(tview.FlexRow).
 		AddItem(view, 0, 1, false)
 
+	history := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	entryView := tview.NewTextView()
+	entryView.SetDynamicColors(true).
+		SetBorder(true)
+	theme := config.GetTheme()
+	searchInput := tview.NewInputField().
+		SetLabel("Search: ").
+		SetLabelColor(tcell.GetColor(theme.Primary))
+
 	return &HostDetailPanel{
-		view:      view,
-		container: container,
-		config:    config,
+		view:        view,
+		container:   container,
+		config:      config,
+		history:     history,
+		entryView:   entryView,
+		searchInput: searchInput,
 	}
 }
 
@@ -50,6 +76,11 @@ func (h *HostDetailPanel) Update() {
 		SetBackgroundColor(tcell.GetColor(theme.Background)).
 		SetBorderColor(tcell.GetColor(theme.Primary))
 
+	if h.showHistory {
+		h.updateHistory(theme)
+		return
+	}
+
 	// Format and display the host details with history
 	content := shared.FormatHostDetail(h.currentMetrics, theme)
 	h.view.SetBackgroundColor(tcell.GetColor(theme.Background))
@@ -84,3 +115,163 @@ func (h *HostDetailPanel) SetVisible(visible bool) {
 		h.container.SetBorder(false)
 	}
 }
+
+// ShowHistory replaces the host details with a table of the whole history
+func (h *HostDetailPanel) ShowHistory() {
+	if h.currentMetrics == nil {
+		return
+	}
+	h.showHistory = true
+	h.expanded = false
+	h.history.Select(1, 0)
+	h.layoutHistory()
+	h.Update()
+}
+
+// HideHistory returns to the host details
+func (h *HostDetailPanel) HideHistory() {
+	if !h.showHistory {
+		return
+	}
+	h.showHistory, h.showSearch = false, false
+	h.container.Clear()
+	h.container.AddItem(h.view, 0, 1, false)
+	h.Update()
+}
+
+// IsHistoryShown returns whether the full history is shown
+func (h *HostDetailPanel) IsHistoryShown() bool {
+	return h.showHistory
+}
+
+// layoutHistory arranges the history table, the expanded entry and the search input
+func (h *HostDetailPanel) layoutHistory() {
+	h.container.Clear()
+	h.container.AddItem(h.history, 0, 2, false)
+	if h.expanded {
+		h.container.AddItem(h.entryView, 0, 1, false)
+	}
+	if h.showSearch {
+		h.container.AddItem(h.searchInput, 1, 0, true)
+	}
+}
+
+// updateHistory refreshes the history table, keeping the selected entry selected
+func (h *HostDetailPanel) updateHistory(theme *shared.Theme) {
+	selected, hasSelection := h.SelectedEntry()
+	h.entries = shared.FilterHistory(h.currentMetrics.GetRecentHistory(math.MaxInt), h.failuresOnly, h.search)
+
+	title := fmt.Sprintf(" [%s]History: %s (%d) ", theme.Primary, h.currentHost, len(h.entries))
+	if h.failuresOnly {
+		title += "[failures] "
+	}
+	if h.search != "" {
+		title += fmt.Sprintf("[\"%s\"] ", tview.Escape(h.search))
+	}
+	h.container.SetTitle(title)
+
+	h.history.Clear()
+	h.history.
+		SetSelectedStyle(tcell.StyleDefault.
+			Background(tcell.GetColor(theme.SelectionBg)).
+			Foreground(tcell.GetColor(theme.SelectionFg))).
+		SetBackgroundColor(tcell.GetColor(theme.Background))
+	for col, header := range []string{"Time", "Status", "RTT", "Details"} {
+		h.history.SetCell(0, col, tview.NewTableCell(header).
+			SetTextColor(tcell.GetColor(theme.TableHeader)).
+			SetSelectable(false))
+	}
+	row := 1
+	for i, e := range h.entries {
+		status, color, details := shared.HistoryEntryStatus(e, theme)
+		h.history.SetCell(i+1, 0, tview.NewTableCell(e.Timestamp.Format("15:04:05")).
+			SetTextColor(tcell.GetColor(theme.Timestamp)))
+		h.history.SetCell(i+1, 1, tview.NewTableCell(status).
+			SetTextColor(tcell.GetColor(color)))
+		h.history.SetCell(i+1, 2, tview.NewTableCell(shared.DurationFormater(e.RTT)).
+			SetTextColor(tcell.GetColor(theme.Primary)))
+		h.history.SetCell(i+1, 3, tview.NewTableCell(details).
+			SetTextColor(tcell.GetColor(theme.Primary)))
+		if hasSelection && e == selected {
+			row = i + 1
+		}
+	}
+	h.history.Select(min(row, max(len(h.entries), 1)), 0)
+
+	h.entryView.SetBackgroundColor(tcell.GetColor(theme.Background))
+	h.entryView.SetBorderColor(tcell.GetColor(theme.Separator))
+	if e, ok := h.SelectedEntry(); ok {
+		h.entryView.SetText(shared.FormatHistoryEntry(e, theme))
+	} else {
+		h.entryView.SetText("")
+	}
+}
+
+// SelectedEntry returns the history entry selected in the table
+func (h *HostDetailPanel) SelectedEntry() (stats.HistoryEntry, bool) {
+	row, _ := h.history.GetSelection()
+	if row < 1 || row > len(h.entries) {
+		return stats.HistoryEntry{}, false
+	}
+	return h.entries[row-1], true
+}
+
+// ToggleExpanded shows or hides all fields of the selected entry
+func (h *HostDetailPanel) ToggleExpanded() {
+	h.expanded = !h.expanded
+	h.layoutHistory()
+	h.Update()
+}
+
+// ToggleFailuresOnly switches between all entries and failed probes only
+func (h *HostDetailPanel) ToggleFailuresOnly() {
+	h.failuresOnly = !h.failuresOnly
+	h.Update()
+}
+
+// SetSearch shows only entries whose error or event text contains text
+func (h *HostDetailPanel) SetSearch(text string) {
+	h.search = text
+	h.Update()
+}
+
+// GetSearch returns the current search text
+func (h *HostDetailPanel) GetSearch() string {
+	return h.search
+}
+
+// ShowSearchInput shows the search input below the history
+func (h *HostDetailPanel) ShowSearchInput() {
+	h.showSearch = true
+	h.searchInput.SetText(h.search)
+	h.layoutHistory()
+}
+
+// HideSearchInput hides the search input
+func (h *HostDetailPanel) HideSearchInput() {
+	h.showSearch = false
+	h.layoutHistory()
+}
+
+// IsSearchShown returns whether the search input is shown
+func (h *HostDetailPanel) IsSearchShown() bool {
+	return h.showSearch
+}
+
+// GetSearchInput returns the search input field
+func (h *HostDetailPanel) GetSearchInput() *tview.InputField {
+	return h.searchInput
+}
+
+// ScrollHistory moves the history selection by n rows, clamped to the entries
+func (h *HostDetailPanel) ScrollHistory(n int) {
+	row, _ := h.history.GetSelection()
+	h.history.Select(min(max(row+n, 1), max(len(h.entries), 1)), 0)
+	h.Update()
+}
+
+// HistoryPageSize returns the number of rows scrolled by a page
+func (h *HostDetailPanel) HistoryPageSize() int {
+	_, _, _, height := h.history.GetRect()
+	return max(height/2, 1) // Reasonable page size
+}
@@ -0,0 +1,70 @@
+package panels
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/servak/mping/internal/prober"
+	"github.com/servak/mping/internal/stats"
+	"github.com/servak/mping/internal/ui/shared"
+)
+
+func TestHostDetailPanelHistory(t *testing.T) {
+	mm := stats.NewMetricsManager()
+	now := time.Now()
+	mm.Register("google.com", "google.com")
+	for i := range 15 {
+		mm.Record(&prober.Event{Key: "google.com", Result: prober.SUCCESS, SentTime: now.Add(time.Duration(i) * time.Second), Rtt: time.Millisecond})
+	}
+	mm.Record(&prober.Event{Key: "google.com", Result: prober.TIMEOUT, SentTime: now.Add(15 * time.Second), Message: "timeout"})
+	mm.Record(&prober.Event{Key: "google.com", Result: prober.FAILED, SentTime: now.Add(16 * time.Second), Message: "connection refused"})
+
+	ms := mm.SortBy(stats.Host, true)
+	if len(ms) != 1 || ms[0].GetFailed() != 2 {
+		t.Fatal("Expected events to be recorded")
+	}
+	metric := ms[0]
+
+	panel := NewHostDetailPanel(shared.DefaultConfig())
+	panel.SetMetrics(metric)
+	panel.ShowHistory()
+	if !panel.IsHistoryShown() {
+		t.Fatal("Expected the history to be shown")
+	}
+	// The whole history, not just the last 10 entries
+	if rows := panel.history.GetRowCount(); rows != 18 {
+		t.Errorf("Expected a header and 17 entries, got %d rows", rows)
+	}
+	if e, ok := panel.SelectedEntry(); !ok || e.Error != "connection refused" {
+		t.Errorf("Expected the newest entry to be selected, got %+v", e)
+	}
+
+	panel.ScrollHistory(1)
+	panel.Update()
+	if e, _ := panel.SelectedEntry(); e.Error != "timeout" {
+		t.Errorf("Expected the selection to move and stay on the entry, got %+v", e)
+	}
+	panel.ToggleExpanded()
+	if text := panel.entryView.GetText(true); !strings.Contains(text, "Error: timeout") {
+		t.Errorf("Expected the expanded entry, got %q", text)
+	}
+
+	panel.ToggleFailuresOnly()
+	if rows := panel.history.GetRowCount(); rows != 3 {
+		t.Errorf("Expected 2 failures, got %d rows", rows-1)
+	}
+	panel.SetSearch("REFUSED")
+	if rows := panel.history.GetRowCount(); rows != 2 {
+		t.Errorf("Expected 1 matching entry, got %d rows", rows-1)
+	}
+	panel.ScrollHistory(10)
+	if e, _ := panel.SelectedEntry(); e.Error != "connection refused" {
+		t.Errorf("Expected the selection to stay within the entries, got %+v", e)
+	}
+
+	panel.HideHistory()
+	if panel.IsHistoryShown() {
+		t.Error("Expected the history to be hidden")
+	}
+}
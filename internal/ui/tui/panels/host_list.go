@@ -2,6 +2,7 @@ package panels
 
 import (
 	"fmt"
+	"slices"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
@@ -53,7 +54,8 @@ func NewHostListPanel(state HostListParams, mm stats.MetricsProvider, config *sh
 func (h *HostListPanel) Update() {
 	// Get filtered metrics based on current state
 	metrics := h.getFilteredMetrics()
-	tableData := shared.NewTableData(metrics, h.renderState.GetSortKey(), h.renderState.IsAscending())
+	tableData := shared.NewTableData(metrics, h.renderState.GetSortKey(), h.renderState.IsAscending(), h.columns())
+	h.addSparklines(tableData)
 
 	// Clear existing content and repopulate
 	h.table.Clear()
@@ -93,14 +95,24 @@ func (h *HostListPanel) Update() {
 
 // getFilteredMetrics returns filtered metrics based on current state
 func (h *HostListPanel) getFilteredMetrics() []stats.Metrics {
-	metrics := h.mm.SortBy(h.renderState.GetSortKey(), h.renderState.IsAscending())
-	return shared.FilterMetrics(metrics, h.renderState.GetFilter())
+	k, ascending := h.renderState.GetSortKey(), h.renderState.IsAscending()
+	metrics := shared.FilterMetrics(h.mm.SortBy(k, ascending), h.renderState.GetFilter())
+	metrics = shared.WindowMetrics(metrics, h.renderState.GetStatsWindow(), k, ascending)
+	return stats.GroupMetrics(metrics)
+}
+
+// columns returns the optional table columns to show
+func (h *HostListPanel) columns() shared.Columns {
+	return shared.Columns{
+		Spread:   h.renderState.ShowSpreadColumns(),
+		Windowed: h.renderState.GetStatsWindow() > 0,
+	}
 }
 
 // updateSelectedHost updates the selection state based on current table selection
 func (h *HostListPanel) updateSelectedHost() {
 	metrics := h.getFilteredMetrics()
-	tableData := shared.NewTableData(metrics, h.renderState.GetSortKey(), h.renderState.IsAscending())
+	tableData := shared.NewTableData(metrics, h.renderState.GetSortKey(), h.renderState.IsAscending(), h.columns())
 	selectedHost := h.GetSelectedHost(tableData)
 
 	// Only update if the selection actually changed to avoid loops
@@ -218,10 +230,18 @@ func (h *HostListPanel) populateTableFromData(tableData *shared.TableData) {
 		tview.AlignRight,  // Avg
 		tview.AlignRight,  // Best
 		tview.AlignRight,  // Worst
+		tview.AlignRight,  // Jitter
+		tview.AlignRight,  // StdDev
+		tview.AlignRight,  // P50
+		tview.AlignRight,  // P90
+		tview.AlignRight,  // P95
+		tview.AlignRight,  // P99
 		tview.AlignCenter, // LastSuccTime
 		tview.AlignCenter, // LastFailTime
 		tview.AlignLeft,   // FAIL Reason
 	}
+	alignments = shared.VisibleColumns(alignments, h.columns())
+	alignments = slices.Insert(alignments, 1, tview.AlignLeft) // History
 
 	// Get theme for theme-aware colors
 	theme := h.config.GetTheme()
@@ -260,6 +280,36 @@ func (h *HostListPanel) populateTableFromData(tableData *shared.TableData) {
 	}
 }
 
+const (
+	minSparklineWidth = 10
+	maxSparklineWidth = stats.DefaultHistorySize
+)
+
+// addSparklines inserts a column with a sparkline of each host's recent RTTs
+// after the host name. The sparklines fill the width left by the other
+// columns, within limits.
+func (h *HostListPanel) addSparklines(tableData *shared.TableData) {
+	width := h.sparklineWidth(tableData)
+	failColor := h.config.GetTheme().Error
+	tableData.Headers = slices.Insert(tableData.Headers, 1, "History")
+	for i, m := range tableData.Metrics {
+		tableData.Rows[i] = slices.Insert(tableData.Rows[i], 1, shared.Sparkline(m.GetRecentHistory(maxSparklineWidth), width, failColor))
+	}
+}
+
+// sparklineWidth returns the width the other columns leave for the sparklines
+func (h *HostListPanel) sparklineWidth(tableData *shared.TableData) int {
+	_, _, available, _ := h.table.GetInnerRect()
+	for col, header := range tableData.Headers {
+		width := tview.TaggedStringWidth(header)
+		for _, row := range tableData.Rows {
+			width = max(width, tview.TaggedStringWidth(row[col]))
+		}
+		available -= width + 5 // Cell padding and separator
+	}
+	return min(max(available-5, minSparklineWidth), maxSparklineWidth)
+}
+
 // restoreSelection finds and selects the row containing the specified host
 func (h *HostListPanel) restoreSelection(tableData *shared.TableData, selectedHost string) {
 	for i, metric := range tableData.Metrics {
@@ -2,8 +2,11 @@ package panels
 
 import (
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/servak/mping/internal/prober"
 	"github.com/servak/mping/internal/stats"
 	"github.com/servak/mping/internal/ui/shared"
 )
@@ -14,17 +17,21 @@ type mockState struct {
 	ascending    bool
 	filter       string
 	selectedHost string
+	statsWindow  time.Duration
+	spread       bool
 }
 
-func (m *mockState) GetSortKey() stats.Key       { return m.sortKey }
-func (m *mockState) SetSortKey(key stats.Key)    { m.sortKey = key }
-func (m *mockState) IsAscending() bool           { return m.ascending }
-func (m *mockState) ReverseSort()                { m.ascending = !m.ascending }
-func (m *mockState) GetFilter() string           { return m.filter }
-func (m *mockState) SetFilter(filter string)     { m.filter = filter }
-func (m *mockState) ClearFilter()                { m.filter = "" }
-func (m *mockState) GetSelectedHost() string     { return m.selectedHost }
-func (m *mockState) SetSelectedHost(host string) { m.selectedHost = host }
+func (m *mockState) GetSortKey() stats.Key         { return m.sortKey }
+func (m *mockState) SetSortKey(key stats.Key)      { m.sortKey = key }
+func (m *mockState) IsAscending() bool             { return m.ascending }
+func (m *mockState) ReverseSort()                  { m.ascending = !m.ascending }
+func (m *mockState) GetFilter() string             { return m.filter }
+func (m *mockState) SetFilter(filter string)       { m.filter = filter }
+func (m *mockState) ClearFilter()                  { m.filter = "" }
+func (m *mockState) GetSelectedHost() string       { return m.selectedHost }
+func (m *mockState) SetSelectedHost(host string)   { m.selectedHost = host }
+func (m *mockState) GetStatsWindow() time.Duration { return m.statsWindow }
+func (m *mockState) ShowSpreadColumns() bool       { return m.spread }
 
 func newMockState() *mockState {
 	return &mockState{
@@ -292,7 +299,7 @@ func TestHostListPanelRestoreSelection(t *testing.T) {
 		stats.NewMetrics("google.com", 1),
 		stats.NewMetrics("example.com", 1),
 	}
-	tableData := shared.NewTableData(metrics, stats.Success, false)
+	tableData := shared.NewTableData(metrics, stats.Success, false, shared.Columns{})
 
 	// Test restoreSelection doesn't panic
 	defer func() {
@@ -304,3 +311,25 @@ func TestHostListPanelRestoreSelection(t *testing.T) {
 	panel.restoreSelection(tableData, "google.com")
 	panel.restoreSelection(tableData, "nonexistent.com")
 }
+
+func TestHostListPanelSparklineColumn(t *testing.T) {
+	mm := stats.NewMetricsManager()
+	state := newMockState()
+	config := shared.DefaultConfig()
+	panel := NewHostListPanel(state, mm, config)
+
+	now := time.Now()
+	mm.Register("google.com", "google.com")
+	mm.Record(&prober.Event{Key: "google.com", Result: prober.SUCCESS, SentTime: now, Rtt: 10 * time.Millisecond})
+	mm.Record(&prober.Event{Key: "google.com", Result: prober.TIMEOUT, SentTime: now.Add(time.Second), Message: "timeout"})
+	mm.Record(&prober.Event{Key: "google.com", Result: prober.SUCCESS, SentTime: now.Add(2 * time.Second), Rtt: 20 * time.Millisecond})
+	panel.Update()
+
+	if header := panel.table.GetCell(0, 1).Text; !strings.Contains(header, "History") {
+		t.Fatalf("Expected the History column after Host, got %q", header)
+	}
+	spark := strings.TrimSpace(panel.table.GetCell(1, 1).Text)
+	if !strings.HasPrefix(spark, "▁") || !strings.HasSuffix(spark, "█") || !strings.Contains(spark, "×") {
+		t.Errorf("Unexpected sparkline %q", spark)
+	}
+}
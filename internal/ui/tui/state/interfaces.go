@@ -1,6 +1,10 @@
 package state
 
-import "github.com/servak/mping/internal/stats"
+import (
+	"time"
+
+	"github.com/servak/mping/internal/stats"
+)
 
 // SelectionState manages selected host state
 type SelectionState interface {
@@ -23,12 +27,27 @@ type FilterState interface {
 	ClearFilter()
 }
 
+// WindowState manages the period the statistics cover
+type WindowState interface {
+	GetStatsWindow() time.Duration
+	CycleStatsWindow()
+}
+
+// ColumnState manages which optional table columns are shown
+type ColumnState interface {
+	ShowSpreadColumns() bool
+	SetSpreadColumns(show bool)
+	ToggleSpreadColumns()
+}
+
 // RenderState provides read-only access for rendering
 type RenderState interface {
 	GetSortKey() stats.Key
 	IsAscending() bool
 	GetFilter() string
 	GetSelectedHost() string
+	GetStatsWindow() time.Duration
+	ShowSpreadColumns() bool
 }
 
 // FullUIState provides complete access to all UI state
@@ -37,5 +56,7 @@ type FullUIState interface {
 	SelectionState
 	SortState
 	FilterState
+	WindowState
+	ColumnState
 	RenderState
 }
\ No newline at end of file
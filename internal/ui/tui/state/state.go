@@ -2,6 +2,7 @@ package state
 
 import (
 	"sync"
+	"time"
 
 	"github.com/servak/mping/internal/stats"
 )
@@ -13,6 +14,8 @@ type UIState struct {
 	ascending    bool
 	filterText   string
 	selectedHost string
+	statsWindow  time.Duration // 0 for lifetime totals
+	spread       bool          // Show the RTT spread columns
 }
 
 // NewUIState creates a new UIState with defaults
@@ -81,3 +84,43 @@ func (s *UIState) ClearFilter() {
 	defer s.mu.Unlock()
 	s.filterText = ""
 }
+
+// WindowState implementation
+func (s *UIState) GetStatsWindow() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.statsWindow
+}
+
+// CycleStatsWindow switches lifetime -> each of stats.StatsWindows -> lifetime
+func (s *UIState) CycleStatsWindow() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	windows := append([]time.Duration{0}, stats.StatsWindows...)
+	for i, w := range windows {
+		if w == s.statsWindow {
+			s.statsWindow = windows[(i+1)%len(windows)]
+			return
+		}
+	}
+	s.statsWindow = 0
+}
+
+// ColumnState implementation
+func (s *UIState) ShowSpreadColumns() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.spread
+}
+
+func (s *UIState) SetSpreadColumns(show bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.spread = show
+}
+
+func (s *UIState) ToggleSpreadColumns() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.spread = !s.spread
+}
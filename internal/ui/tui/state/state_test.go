@@ -192,7 +192,39 @@ func TestUIStateInterfaces(t *testing.T) {
 	var _ SelectionState = state
 	var _ SortState = state
 	var _ FilterState = state
+	var _ WindowState = state
+	var _ ColumnState = state
 	var _ RenderState = state
 	var _ FullUIState = state
 }
 
+
+func TestUIStateStatsWindow(t *testing.T) {
+	state := NewUIState()
+	if state.GetStatsWindow() != 0 {
+		t.Errorf("Expected lifetime statistics by default, got %v", state.GetStatsWindow())
+	}
+
+	// Cycles through every window and back to lifetime
+	for _, want := range append(stats.StatsWindows, 0) {
+		state.CycleStatsWindow()
+		if got := state.GetStatsWindow(); got != want {
+			t.Errorf("CycleStatsWindow() = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestUIStateSpreadColumns(t *testing.T) {
+	state := NewUIState()
+	if state.ShowSpreadColumns() {
+		t.Error("Expected the spread columns to be hidden by default")
+	}
+	state.ToggleSpreadColumns()
+	if !state.ShowSpreadColumns() {
+		t.Error("ToggleSpreadColumns() should show the spread columns")
+	}
+	state.SetSpreadColumns(false)
+	if state.ShowSpreadColumns() {
+		t.Error("SetSpreadColumns(false) should hide the spread columns")
+	}
+}